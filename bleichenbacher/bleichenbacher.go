@@ -0,0 +1,184 @@
+package bleichenbacher
+
+import (
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"../rsa"
+)
+
+// Bleichenbacher's 1998 attack on PKCS#1 v1.5 encryption padding ("Chosen Ciphertext
+// Attacks Against Protocols Based on the RSA Encryption Standard PKCS #1").
+//
+// Given an oracle that tells whether the decryption of a ciphertext starts with 0x00 0x02,
+// i.e. whether 2B <= m < 3B with B = 2^(8(k - 2)), we multiply the plaintext by chosen
+// values s (by multiplying the ciphertext by s^e) and each conforming s narrows down the
+// intervals in which m can be.
+
+// Tells whether the plaintext of c is PKCS#1 conforming. It must be safe for concurrent
+// use if the attack is run with more than one worker.
+type Oracle func(c *big.Int) bool
+
+type interval struct {
+	a *big.Int
+	b *big.Int
+}
+
+type attack struct {
+	key *rsa.PublicKey
+	oracle Oracle
+	workers int
+	queries int64
+	c0 *big.Int
+	b2 *big.Int // 2B
+	b3 *big.Int // 3B
+}
+
+var one = big.NewInt(1)
+
+func ceilDiv(x *big.Int, y *big.Int) *big.Int {
+	q, m := new(big.Int).DivMod(x, y, new(big.Int))
+	if m.Sign() != 0 { q.Add(q, one) }
+	return q
+}
+
+// Tests whether c0 * s^e mod n is conforming
+func (this *attack) conforming(s *big.Int) bool {
+	atomic.AddInt64(&this.queries, 1)
+	c := rsa.Encrypt(this.key, s)
+	c.Mul(c, this.c0)
+	c.Mod(c, this.key.N)
+	return this.oracle(c)
+}
+
+// Returns the smallest s >= start (and <= max, if not nil) such that c0 * s^e is conforming.
+// The values are tested in batches of `workers` values in parallel.
+func (this *attack) search(start *big.Int, max *big.Int) (*big.Int, bool) {
+	s := new(big.Int).Set(start)
+	for {
+		batch := make([]*big.Int, 0, this.workers)
+		for i := 0; i < this.workers; i++ {
+			if max != nil && s.Cmp(max) > 0 { break }
+			batch = append(batch, new(big.Int).Set(s))
+			s.Add(s, one)
+		}
+		if len(batch) == 0 { return nil, false }
+
+		results := make([]bool, len(batch))
+		if len(batch) == 1 {
+			results[0] = this.conforming(batch[0])
+		} else {
+			var wg sync.WaitGroup
+			for i := range batch {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					results[i] = this.conforming(batch[i])
+				}(i)
+			}
+			wg.Wait()
+		}
+		for i, ok := range results {
+			if ok { return batch[i], true }
+		}
+	}
+}
+
+// Step 3: narrows the set of solutions with the new s
+func (this *attack) narrow(intervals []interval, s *big.Int) []interval {
+	n := this.key.N
+	var output []interval
+	for _, m := range intervals {
+		// (a * s - 3B + 1) / n <= r <= (b * s - 2B) / n
+		rMin := new(big.Int).Mul(m.a, s)
+		rMin.Sub(rMin, this.b3)
+		rMin.Add(rMin, one)
+		rMin = ceilDiv(rMin, n)
+		rMax := new(big.Int).Mul(m.b, s)
+		rMax.Sub(rMax, this.b2)
+		rMax.Div(rMax, n)
+
+		for r := rMin; r.Cmp(rMax) <= 0; r = new(big.Int).Add(r, one) {
+			rn := new(big.Int).Mul(r, n)
+			// [max(a, ceil((2B + rn) / s)), min(b, floor((3B - 1 + rn) / s))]
+			a := ceilDiv(new(big.Int).Add(this.b2, rn), s)
+			if a.Cmp(m.a) < 0 { a = m.a }
+			b := new(big.Int).Add(this.b3, rn)
+			b.Sub(b, one)
+			b.Div(b, s)
+			if b.Cmp(m.b) > 0 { b = m.b }
+			if a.Cmp(b) <= 0 {
+				output = mergeInterval(output, interval{a, b})
+			}
+		}
+	}
+	return output
+}
+
+// Adds the interval to the list, merging it with any overlapping one
+func mergeInterval(intervals []interval, i interval) []interval {
+	for j, other := range intervals {
+		if i.a.Cmp(other.b) <= 0 && other.a.Cmp(i.b) <= 0 {
+			merged := interval{other.a, other.b}
+			if i.a.Cmp(merged.a) < 0 { merged.a = i.a }
+			if i.b.Cmp(merged.b) > 0 { merged.b = i.b }
+			rest := append(append([]interval{}, intervals[0:j]...), intervals[j + 1:]...)
+			return mergeInterval(rest, merged)
+		}
+	}
+	return append(intervals, i)
+}
+
+// Recovers the plaintext of c, which must be PKCS#1 conforming. Returns the plaintext
+// (including the padding) and the number of oracle queries.
+func Attack(key *rsa.PublicKey, c *big.Int, oracle Oracle, workers int) (*big.Int, int) {
+	if workers < 1 { workers = 1 }
+	k := (key.N.BitLen() + 7) / 8
+	bigB := new(big.Int).Lsh(one, uint(8 * (k - 2)))
+	this := &attack{key: key, oracle: oracle, workers: workers, c0: c}
+	this.b2 = new(big.Int).Mul(big.NewInt(2), bigB)
+	this.b3 = new(big.Int).Mul(big.NewInt(3), bigB)
+	n := key.N
+
+	// Step 1: c is already conforming, so s0 = 1
+	intervals := []interval{{new(big.Int).Set(this.b2), new(big.Int).Sub(this.b3, one)}}
+
+	// Step 2a: s1 is the smallest s >= n / 3B that gives a conforming plaintext
+	s, _ := this.search(ceilDiv(n, this.b3), nil)
+	intervals = this.narrow(intervals, s)
+
+	for {
+		// Step 4: done when there's only one interval of size 1
+		if len(intervals) == 1 && intervals[0].a.Cmp(intervals[0].b) == 0 {
+			return intervals[0].a, int(this.queries)
+		}
+
+		if len(intervals) > 1 {
+			// Step 2b: several intervals left, search for the next s
+			s, _ = this.search(new(big.Int).Add(s, one), nil)
+		} else {
+			// Step 2c: one interval left [a, b]. Choose r >= 2 * (b * s - 2B) / n, and search s in
+			// [(2B + rn) / b, (3B + rn) / a) for each r. This roughly halves the interval each time.
+			a, b := intervals[0].a, intervals[0].b
+			r := new(big.Int).Mul(b, s)
+			r.Sub(r, this.b2)
+			r.Mul(r, big.NewInt(2))
+			r = ceilDiv(r, n)
+			for {
+				rn := new(big.Int).Mul(r, n)
+				sMin := ceilDiv(new(big.Int).Add(this.b2, rn), b)
+				sMax := ceilDiv(new(big.Int).Add(this.b3, rn), a)
+				sMax.Sub(sMax, one)
+				found, ok := this.search(sMin, sMax)
+				if ok {
+					s = found
+					break
+				}
+				r.Add(r, one)
+			}
+		}
+
+		// Step 3
+		intervals = this.narrow(intervals, s)
+	}
+}
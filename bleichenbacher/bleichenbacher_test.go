@@ -0,0 +1,28 @@
+package bleichenbacher
+
+import (
+	"math/big"
+	"testing"
+	"../cryptoutil"
+	"../rsa"
+)
+
+func TestAttack(t *testing.T) {
+	for _, workers := range []int{1, 8} {
+		key := rsa.GenerateKey(256, 3)
+		k := (key.N.BitLen() + 7) / 8
+		oracle := func(c *big.Int) bool {
+			m := rsa.Decrypt(key, c).FillBytes(make([]byte, k))
+			return cryptoutil.IsPkcs1v15Conforming(m, k)
+		}
+
+		padded, _ := cryptoutil.Pkcs1v15Padding([]byte("kick it, CC"), k)
+		c := rsa.Encrypt(&key.PublicKey, new(big.Int).SetBytes(padded))
+		m, queries := Attack(&key.PublicKey, c, oracle, workers)
+		message, ok := cryptoutil.RemovePkcs1v15Padding(m.FillBytes(make([]byte, k)), k)
+		if !ok || string(message) != "kick it, CC" {
+			t.Errorf("%d workers: could not recover message (%x)", workers, m)
+		}
+		t.Logf("%d workers: %d queries", workers, queries)
+	}
+}
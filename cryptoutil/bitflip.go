@@ -0,0 +1,60 @@
+package cryptoutil
+
+// Bit-flipping forgeries, as done manually in q16. Given some ciphertext and the
+// plaintext known to be at a given offset, these functions modify the ciphertext
+// so that it decrypts to the desired plaintext instead.
+
+// Modifies a CBC ciphertext so that the bytes at `offset`, which currently decrypt
+// to `known`, decrypt to `desired` instead. This works because when decrypting,
+// each block is XORed against the previous ciphertext block (or the IV for the
+// first block), so we flip the bits of that previous block with known ^ desired.
+//
+// Returns the new ciphertext, the new IV and the index of the block that gets
+// scrambled by the change (i.e. the block that has been modified, which won't
+// decrypt to anything meaningful anymore). If the target is in the first block,
+// only the IV is modified and the returned index is -1, since no block gets scrambled.
+//
+// The target must fit within a single block, otherwise the flipped block would itself
+// be one of the target blocks. In that case, or if the offset is out of range or the
+// IV isn't one block long, `false` is returned.
+func CBCBitFlip(ciphertext []byte, iv []byte, offset int, known []byte, desired []byte) ([]byte, []byte, int, bool) {
+	bs := 16 // block size
+	if len(known) != len(desired) || len(known) == 0 { return ciphertext, iv, 0, false }
+	if offset < 0 || offset + len(known) > len(ciphertext) { return ciphertext, iv, 0, false }
+	if len(iv) != bs { return ciphertext, iv, 0, false }
+
+	targetBlock := offset / bs
+	if (offset + len(known) - 1) / bs != targetBlock { return ciphertext, iv, 0, false }
+
+	newCiphertext := AppendBytes([]byte{}, ciphertext)
+	newIv := AppendBytes([]byte{}, iv)
+
+	// The block that gets XORed against the target block
+	var previousBlock []byte
+	if targetBlock == 0 {
+		previousBlock = newIv
+	} else {
+		previousBlock = newCiphertext[(targetBlock - 1) * bs:targetBlock * bs]
+	}
+
+	start := offset % bs
+	for i := 0; i < len(known); i++ {
+		previousBlock[start + i] ^= known[i] ^ desired[i]
+	}
+
+	return newCiphertext, newIv, targetBlock - 1, true
+}
+
+// Same as CBCBitFlip but for CTR-mode ciphertexts. Since the plaintext is simply XORed
+// against the keystream, we can flip the bits of the target bytes directly and no
+// block gets scrambled. Returns `false` if the target is out of range.
+func CTRBitFlip(ciphertext []byte, offset int, known []byte, desired []byte) ([]byte, bool) {
+	if len(known) != len(desired) { return ciphertext, false }
+	if offset < 0 || offset + len(known) > len(ciphertext) { return ciphertext, false }
+
+	output := AppendBytes([]byte{}, ciphertext)
+	for i := 0; i < len(known); i++ {
+		output[offset + i] ^= known[i] ^ desired[i]
+	}
+	return output, true
+}
@@ -0,0 +1,58 @@
+package cryptoutil
+
+import (
+	"crypto/aes"
+)
+
+// CBC-MAC: the message is padded with PKCS#7 and encrypted with CBC, and the MAC is
+// the last ciphertext block. The IV must be fixed (usually zero) and the messages must
+// have a fixed length, otherwise forgeries are trivial (see q49 and q50).
+func AES128CBCMAC(message []byte, key []byte, iv []byte) []byte {
+	padded := AppendBytes([]byte{}, message)
+	padded = Pkcs7padding(padded, len(padded) + Pkcs7paddingCount(padded))
+	encrypted := AES128CBCEncrypt(padded, key, iv)
+	return encrypted[len(encrypted) - 16:]
+}
+
+// Multiplies a block by x in GF(2^128), as used to derive the CMAC subkeys.
+func cmacDouble(input []byte) []byte {
+	output := make([]byte, 16)
+	for i := 0; i < 15; i++ {
+		output[i] = input[i] << 1 | input[i + 1] >> 7
+	}
+	output[15] = input[15] << 1
+	if input[0] & 0x80 != 0 {
+		output[15] ^= 0x87
+	}
+	return output
+}
+
+// CMAC (RFC 4493), which fixes CBC-MAC for variable-length messages. The last block
+// is XORed with a subkey derived from the key before being encrypted, with a different
+// subkey depending on whether the block had to be padded.
+func AES128CMAC(message []byte, key []byte) []byte {
+	bs := 16
+	cypher, _ := aes.NewCipher(key)
+	l := make([]byte, bs)
+	cypher.Encrypt(l, l)
+	k1 := cmacDouble(l)
+	k2 := cmacDouble(k1)
+
+	// The last block is padded with 0x80 followed by zeros, unless it's complete
+	var lastBlock []byte
+	n := (len(message) + bs - 1) / bs
+	if n > 0 && len(message) % bs == 0 {
+		lastBlock = RepeatingKeyXor(message[(n - 1) * bs:], k1)
+	} else {
+		if n == 0 { n = 1 }
+		lastBlock = AppendBytes([]byte{}, message[(n - 1) * bs:])
+		lastBlock = append(lastBlock, 0x80)
+		lastBlock = AppendBytes(lastBlock, FillBytes(0, bs - len(lastBlock)))
+		lastBlock = RepeatingKeyXor(lastBlock, k2)
+	}
+
+	data := AppendBytes([]byte{}, message[0:(n - 1) * bs])
+	data = AppendBytes(data, lastBlock)
+	encrypted := AES128CBCEncrypt(data, key, make([]byte, bs))
+	return encrypted[len(encrypted) - bs:]
+}
@@ -0,0 +1,79 @@
+package cryptoutil
+
+// Constant-time variants of SliceEquals, RemovePkcs7padding, etc. None of these
+// functions branch or index memory based on the content of their inputs, only on
+// their lengths, which are assumed to be public. The results are computed with masks
+// instead: 0xff (or 1) for true, 0 for false.
+
+// Returns 1 if x == y, 0 otherwise
+func ConstantTimeByteEq(x byte, y byte) int {
+	z := ^(x ^ y)
+	z &= z >> 4
+	z &= z >> 2
+	z &= z >> 1
+	return int(z & 1)
+}
+
+// Returns 1 if x == y, 0 otherwise. Both values must be positive.
+func ConstantTimeEq(x int, y int) int {
+	return int((uint64(int64(x ^ y)) - 1) >> 63)
+}
+
+// Returns 1 if x <= y, 0 otherwise. Both values must be positive and less than 2^31.
+func ConstantTimeLessOrEq(x int, y int) int {
+	return int(((int64(x) - int64(y) - 1) >> 63) & 1)
+}
+
+// Returns x if v is 1 and y if v is 0
+func ConstantTimeSelectInt(v int, x int, y int) int {
+	return ^(v - 1) & x | (v - 1) & y
+}
+
+// Returns a copy of x if v is 1 and a copy of y if v is 0. Both slices must have the same length.
+func ConstantTimeSelect(v int, x []byte, y []byte) []byte {
+	mask := byte(-v)
+	output := make([]byte, len(x))
+	for i := 0; i < len(x); i++ {
+		output[i] = x[i] & mask | y[i] & ^mask
+	}
+	return output
+}
+
+// Same as SliceEquals but always compares all the bytes instead of returning on the first mismatch.
+func ConstantTimeEquals(slice1 []byte, slice2 []byte) bool {
+	if len(slice1) != len(slice2) { return false }
+	var v byte
+	for i := 0; i < len(slice1); i++ {
+		v |= slice1[i] ^ slice2[i]
+	}
+	return ConstantTimeByteEq(v, 0) == 1
+}
+
+// Returns table[index] by reading the whole table, so that the memory access pattern (and
+// therefore the cache) doesn't depend on the index.
+func ConstantTimeLookup(table []byte, index int) byte {
+	var output byte
+	for i := 0; i < len(table); i++ {
+		output |= table[i] & byte(-ConstantTimeEq(i, index))
+	}
+	return output
+}
+
+// Checks and removes the PKCS#7 padding, like the removePkcs7padding function in q16 and
+// q17, but without branching on the padding bytes. The last 16 bytes are always checked,
+// whatever the padding size. Returns `false` if the padding is invalid.
+func ConstantTimeRemovePkcs7padding(data []byte) ([]byte, bool) {
+	bs := 16
+	if len(data) == 0 || len(data) % bs != 0 { return data, false }
+
+	paddingSize := int(data[len(data) - 1])
+	valid := ConstantTimeLessOrEq(1, paddingSize) & ConstantTimeLessOrEq(paddingSize, bs)
+	for i := 0; i < bs; i++ {
+		// If the byte is part of the padding, it must be equal to the padding size
+		inPadding := ConstantTimeLessOrEq(i + 1, paddingSize)
+		valid &= ConstantTimeByteEq(data[len(data) - 1 - i], byte(paddingSize)) | (inPadding ^ 1)
+	}
+
+	length := ConstantTimeSelectInt(valid, len(data) - paddingSize, len(data))
+	return data[0:length], valid == 1
+}
@@ -0,0 +1,145 @@
+package cryptoutil
+
+import (
+	"flag"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestConstantTimeFunctions(t *testing.T) {
+	a := []byte("YELLOW SUBMARINE")
+	b := []byte("YELLOW SUBMARINA")
+	if !ConstantTimeEquals(a, a) || ConstantTimeEquals(a, b) || ConstantTimeEquals(a, a[1:]) {
+		t.Errorf("ConstantTimeEquals failed")
+	}
+	if string(ConstantTimeSelect(1, a, b)) != string(a) || string(ConstantTimeSelect(0, a, b)) != string(b) {
+		t.Errorf("ConstantTimeSelect failed")
+	}
+	for i := 0; i < len(a); i++ {
+		if ConstantTimeLookup(a, i) != a[i] {
+			t.Errorf("ConstantTimeLookup failed for index %d", i)
+		}
+	}
+	if ConstantTimeLessOrEq(3, 4) != 1 || ConstantTimeLessOrEq(4, 4) != 1 || ConstantTimeLessOrEq(5, 4) != 0 {
+		t.Errorf("ConstantTimeLessOrEq failed")
+	}
+
+	// Compare against the padding check from q16
+	for i := 0; i < 2000; i++ {
+		data := RandomBytes(32)
+		p := rand.Intn(20)
+		for j := 0; j < p && j < len(data); j++ {
+			data[len(data) - 1 - j] = byte(p)
+		}
+		if i % 5 == 0 { data[len(data) - 1 - rand.Intn(16)] = byte(rand.Intn(256)) }
+
+		expectedValid := true
+		paddingSize := int(data[len(data) - 1])
+		if paddingSize == 0 || paddingSize > 16 {
+			expectedValid = false
+		} else {
+			for j := len(data) - paddingSize; j < len(data); j++ {
+				if int(data[j]) != paddingSize { expectedValid = false }
+			}
+		}
+
+		unpadded, valid := ConstantTimeRemovePkcs7padding(data)
+		if valid != expectedValid || (valid && len(unpadded) != len(data) - paddingSize) {
+			t.Errorf("%x: expected %v, got %v", data, expectedValid, valid)
+		}
+	}
+}
+
+// Timing test harness, based on dudect (https://github.com/oreparaz/dudect). The function
+// is run many times with inputs from two classes (typically fixed vs random), in random
+// order. The slowest measurements are discarded, as they are mostly due to interruptions,
+// then Welch's t-test tells whether the two classes have different timing distributions.
+//
+// dudect considers |t| > 10 to be a definite leak.
+const timingThreshold = 10
+
+func timingTStatistic(measurements int, batch int, input func(class int) []byte, f func([]byte)) float64 {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	classes := make([]int, measurements)
+	inputs := make([][]byte, measurements)
+	for i := 0; i < measurements; i++ {
+		classes[i] = r.Intn(2)
+		inputs[i] = input(classes[i])
+	}
+
+	times := make([]float64, measurements)
+	for i := 0; i < measurements; i++ {
+		start := time.Now()
+		for j := 0; j < batch; j++ {
+			f(inputs[i])
+		}
+		times[i] = float64(time.Since(start))
+	}
+
+	sorted := append([]float64{}, times...)
+	sort.Float64s(sorted)
+	crop := sorted[measurements * 9 / 10]
+
+	// Welch's t-test
+	var n, sum, sumSquares [2]float64
+	for i := 0; i < measurements; i++ {
+		if times[i] > crop { continue }
+		c := classes[i]
+		n[c]++
+		sum[c] += times[i]
+		sumSquares[c] += times[i] * times[i]
+	}
+	var mean, variance [2]float64
+	for c := 0; c < 2; c++ {
+		mean[c] = sum[c] / n[c]
+		variance[c] = sumSquares[c] / n[c] - mean[c] * mean[c]
+	}
+	return (mean[0] - mean[1]) / math.Sqrt(variance[0] / n[0] + variance[1] / n[1])
+}
+
+// The timing test depends on the load of the machine, so it only runs when asked for with
+// go test -timing
+var timing = flag.Bool("timing", false, "run the timing test of the constant-time functions")
+
+func TestConstantTimeTiming(t *testing.T) {
+	if !*timing {
+		t.Skip("skipping timing test, use -timing to run it")
+	}
+
+	secret := RandomBytes(1024)
+	fixedOrRandom := func(class int) []byte {
+		if class == 0 { return secret }
+		return RandomBytes(1024)
+	}
+
+	// First check that the harness detects the leak in SliceEquals
+	tValue := timingTStatistic(20000, 4, fixedOrRandom, func(data []byte) { SliceEquals(secret, data) })
+	if math.Abs(tValue) < timingThreshold {
+		t.Errorf("SliceEquals: leak not detected (t = %.2f)", tValue)
+	}
+
+	tests := []struct {
+		name string
+		input func(class int) []byte
+		f func([]byte)
+	}{
+		{"ConstantTimeEquals", fixedOrRandom, func(data []byte) { ConstantTimeEquals(secret, data) }},
+		{"ConstantTimeSelect", fixedOrRandom, func(data []byte) { ConstantTimeSelect(int(data[0] & 1), secret, data) }},
+		{"ConstantTimeLookup", fixedOrRandom, func(data []byte) { ConstantTimeLookup(secret[0:256], int(data[1])) }},
+		{"ConstantTimeRemovePkcs7padding", func(class int) []byte {
+			// Valid padding vs random data, which almost always has invalid padding
+			if class == 0 { return Pkcs7padding(RandomBytes(1008), 1024) }
+			return RandomBytes(1024)
+		}, func(data []byte) { ConstantTimeRemovePkcs7padding(data) }},
+	}
+	for _, test := range tests {
+		tValue := timingTStatistic(20000, 16, test.input, test.f)
+		t.Logf("%s: t = %.2f", test.name, tValue)
+		if math.Abs(tValue) > timingThreshold {
+			t.Errorf("%s: possible timing leak (t = %.2f)", test.name, tValue)
+		}
+	}
+}
@@ -26,6 +26,8 @@ func AES128ECBEncrypt(plain []byte, key []byte) []byte {
 	return output
 }
 
+// Note: the IV must not be the key. If it is, anyone who can see the decrypted
+// plaintext of a modified ciphertext can recover the key (see q27).
 func AES128CBCEncrypt(plain []byte, key []byte, iv []byte) []byte {
 	cypher, _ := aes.NewCipher(key)
 	blockSize := 16
@@ -1,7 +1,9 @@
 package cryptoutil
 
 import (
-	"testing"	
+	"testing"
+	"encoding/base64"
+	"encoding/hex"
 )
 
 func TestAES128ECB(t *testing.T) {
@@ -23,4 +25,180 @@ func TestAES128CBC(t *testing.T) {
 	if string(dec) != string(source) {
 		t.Errorf("%s is different from %s", source, dec)
 	}
-}
\ No newline at end of file
+}
+
+func TestCBCBitFlip(t *testing.T) {
+	source := []byte("abcdefghi 123456abcdefghi 123456abcdefghi 123456")
+	key := []byte("1234567890123456")
+	iv := []byte("7777777777777777")
+	enc := AES128CBCEncrypt(source, key, iv)
+
+	// Target in the last block - the previous block gets scrambled
+	newEnc, newIv, scrambled, ok := CBCBitFlip(enc, iv, 34, []byte("cdef"), []byte("WXYZ"))
+	if !ok || scrambled != 1 {
+		t.Errorf("unexpected result: %v, %d", ok, scrambled)
+	}
+	dec := AES128CBCDecrypt(newEnc, key, newIv)
+	if string(dec[0:16]) != string(source[0:16]) || string(dec[32:48]) != "abWXYZghi 123456" {
+		t.Errorf("unexpected plaintext: %s", dec)
+	}
+
+	// Target in the first block - only the IV is modified
+	newEnc, newIv, scrambled, ok = CBCBitFlip(enc, iv, 0, []byte("abc"), []byte("xyz"))
+	if !ok || scrambled != -1 {
+		t.Errorf("unexpected result: %v, %d", ok, scrambled)
+	}
+	dec = AES128CBCDecrypt(newEnc, key, newIv)
+	if string(dec) != "xyz" + string(source[3:]) {
+		t.Errorf("unexpected plaintext: %s", dec)
+	}
+
+	// Target spanning two blocks
+	_, _, _, ok = CBCBitFlip(enc, iv, 14, []byte("5612"), []byte("aaaa"))
+	if ok {
+		t.Errorf("target spanning two blocks should not be accepted")
+	}
+
+	// Missing or short IV
+	for _, badIv := range [][]byte{nil, iv[0:8]} {
+		_, _, _, ok = CBCBitFlip(enc, badIv, 0, []byte("abc"), []byte("xyz"))
+		if ok {
+			t.Errorf("IV of length %d should not be accepted", len(badIv))
+		}
+	}
+}
+
+func TestCTRBitFlip(t *testing.T) {
+	source := []byte("abcdefghi 123456abcdefghi 123456abcdefghi 123456")
+	key := []byte("YELLOW SUBMARINE")
+	enc := AES128CTREncrypt(source, key, 7)
+
+	// Target spanning two blocks - nothing gets scrambled
+	newEnc, ok := CTRBitFlip(enc, 14, []byte("56ab"), []byte("WXYZ"))
+	if !ok {
+		t.Fatalf("could not flip the bits")
+	}
+	dec := AES128CTRDecrypt(newEnc, key, 7)
+	if string(dec) != string(source[0:14]) + "WXYZ" + string(source[18:]) {
+		t.Errorf("unexpected plaintext: %s", dec)
+	}
+
+	// Last bytes of the ciphertext
+	newEnc, ok = CTRBitFlip(enc, len(enc) - 2, []byte("56"), []byte("!!"))
+	dec = AES128CTRDecrypt(newEnc, key, 7)
+	if !ok || string(dec) != string(source[0:len(source) - 2]) + "!!" {
+		t.Errorf("unexpected plaintext: %s", dec)
+	}
+
+	// Out of range offsets
+	for _, offset := range []int{-1, len(enc) - 1, len(enc)} {
+		if _, ok := CTRBitFlip(enc, offset, []byte("ab"), []byte("xy")); ok {
+			t.Errorf("offset %d should not be accepted", offset)
+		}
+	}
+
+	// Mismatched lengths
+	if _, ok := CTRBitFlip(enc, 0, []byte("abc"), []byte("xy")); ok {
+		t.Errorf("mismatched lengths should not be accepted")
+	}
+}
+
+func TestAES128CTR(t *testing.T) {
+	// Test data from q18
+	data, _ := base64.StdEncoding.DecodeString("L77na/nrFsKvynd6HzOoG7GHTLXsTVu9qvY/2syLXzhPweyyMTJULu/6/kXX0KSvoOLSFQ==")
+	dec := AES128CTRDecrypt(data, []byte("YELLOW SUBMARINE"), 0)
+	expected := "Yo, VIP Let's kick it Ice, Ice, baby Ice, Ice, baby "
+	if string(dec) != expected {
+		t.Errorf("%s is different from %s", dec, expected)
+	}
+	enc := AES128CTREncrypt(dec, []byte("YELLOW SUBMARINE"), 0)
+	if !SliceEquals(enc, data) {
+		t.Errorf("%x is different from %x", enc, data)
+	}
+}
+
+func TestAES128CTREdit(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	source := []byte("Yo, VIP Let's kick it Ice, Ice, baby Ice, Ice, baby ")
+	enc := AES128CTREncrypt(source, key, 42)
+
+	edited, ok := AES128CTREdit(enc, key, 42, 19, []byte("IT"))
+	expected := "Yo, VIP Let's kick IT Ice, Ice, baby Ice, Ice, baby "
+	if dec := AES128CTRDecrypt(edited, key, 42); !ok || string(dec) != expected {
+		t.Errorf("%s is different from %s", dec, expected)
+	}
+
+	edited, ok = AES128CTREdit(enc, key, 42, 47, []byte("baby, too cold"))
+	expected = "Yo, VIP Let's kick it Ice, Ice, baby Ice, Ice, baby, too cold"
+	if dec := AES128CTRDecrypt(edited, key, 42); !ok || string(dec) != expected {
+		t.Errorf("%s is different from %s", dec, expected)
+	}
+
+	_, ok = AES128CTREdit(enc, key, 42, len(enc) + 1, []byte("a"))
+	if ok {
+		t.Errorf("offset past the end should not be accepted")
+	}
+}
+
+func TestAES128CTRDecryptAt(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	source := []byte("Yo, VIP Let's kick it Ice, Ice, baby Ice, Ice, baby ")
+	enc := AES128CTREncrypt(source, key, 42)
+
+	dec, ok := AES128CTRDecryptAt(enc[19:40], key, 42, 19)
+	if !ok || string(dec) != string(source[19:40]) {
+		t.Errorf("%s is different from %s", dec, source[19:40])
+	}
+	if _, ok := AES128CTRDecryptAt(enc, key, 42, -1); ok {
+		t.Errorf("negative offset should not be accepted")
+	}
+}
+
+func TestAES128CMAC(t *testing.T) {
+	// Test vectors from RFC 4493
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	message, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+	vectors := []struct {
+		length int
+		expected string
+	}{
+		{0, "bb1d6929e95937287fa37d129b756746"},
+		{16, "070a16b46b4d4144f79bdd9dd04a287c"},
+		{40, "dfa66747de9ae63030ca32611497c827"},
+		{64, "51f0bebf7e3b9d92fc49741779363cfe"},
+	}
+	for _, v := range vectors {
+		mac := hex.EncodeToString(AES128CMAC(message[0:v.length], key))
+		if mac != v.expected {
+			t.Errorf("length %d: %s is different from %s", v.length, mac, v.expected)
+		}
+	}
+}
+
+func TestAES128CBCMAC(t *testing.T) {
+	// Test data from challenge 50
+	mac := AES128CBCMAC([]byte("alert('MZA who was that?');\n"), []byte("YELLOW SUBMARINE"), make([]byte, 16))
+	expected := "296b8d7cb78a243dda4d0a61d33bbdd1"
+	if hex.EncodeToString(mac) != expected {
+		t.Errorf("%x is different from %s", mac, expected)
+	}
+}
+
+func TestPkcs1v15Padding(t *testing.T) {
+	message := []byte("kick it, CC")
+	padded, ok := Pkcs1v15Padding(message, 32)
+	if !ok || len(padded) != 32 || !IsPkcs1v15Conforming(padded, 32) {
+		t.Errorf("invalid padding: %x", padded)
+	}
+	unpadded, ok := RemovePkcs1v15Padding(padded, 32)
+	if !ok || string(unpadded) != string(message) {
+		t.Errorf("%s is different from %s", unpadded, message)
+	}
+	if _, ok := Pkcs1v15Padding(message, 21); ok {
+		t.Errorf("message too long should not be accepted")
+	}
+	padded[5] = 0
+	if _, ok := RemovePkcs1v15Padding(padded, 32); ok {
+		t.Errorf("padding string shorter than 8 bytes should not be accepted")
+	}
+}
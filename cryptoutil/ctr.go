@@ -0,0 +1,99 @@
+package cryptoutil
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+)
+
+// CTR mode, with a 64-bit little-endian nonce and counter.
+
+// Convert an int to a byte slice
+func Int64ToBytes(i int64, byteOrder binary.ByteOrder) []byte {
+	buffer := new(bytes.Buffer)
+	binary.Write(buffer, byteOrder, i)
+	return buffer.Bytes()
+}
+
+// Create the CTR mode keystream, which is AES(nonce || counter, key)
+func CTRKeystream(key []byte, nonce int64, counter int64) []byte {
+	cypher, _ := aes.NewCipher(key)
+	keystreamKey := Int64ToBytes(nonce, binary.LittleEndian)
+	keystreamKey = AppendBytes(keystreamKey, Int64ToBytes(counter, binary.LittleEndian))
+	output := make([]byte, 16)
+	cypher.Encrypt(output, keystreamKey)
+	return output
+}
+
+func AES128CTRDecrypt(encrypted []byte, key []byte, nonce int64) []byte {
+	output, _ := AES128CTRDecryptAt(encrypted, key, nonce, 0)
+	return output
+}
+
+func AES128CTREncrypt(plain []byte, key []byte, nonce int64) []byte {
+	return AES128CTRDecrypt(plain, key, nonce)
+}
+
+// Decrypts (or encrypts) the given data as if it was located at `offset` in the
+// CTR stream. Rather than generating the keystream from zero, we directly seek to
+// the right counter, since each keystream block only depends on the nonce and counter.
+// Returns `false` if the offset is negative.
+func AES128CTRDecryptAt(encrypted []byte, key []byte, nonce int64, offset int) ([]byte, bool) {
+	if offset < 0 { return nil, false }
+	output := make([]byte, len(encrypted))
+	keystreamIndex := offset % 16
+	counter := int64(offset / 16)
+	var keystream []byte
+	// Loop through each byte of the encrypted string and XOR it against the
+	// keystream. Generate new keystreams as needed, incrementing the counter
+	// every time.
+	for i := 0; i < len(encrypted); i++ {
+		if i == 0 || keystreamIndex >= 16 {
+			keystream = CTRKeystream(key, nonce, counter)
+			counter++
+			if i > 0 { keystreamIndex = 0 }
+		}
+		output[i] = encrypted[i] ^ keystream[keystreamIndex]
+		keystreamIndex++
+	}
+	return output, true
+}
+
+// Random-access write: replaces the plaintext at `offset` with `newtext` and returns the
+// new ciphertext. Only the edited bytes are re-encrypted. The ciphertext is extended
+// if newtext goes past its end. Returns `false` if the offset is out of range.
+func AES128CTREdit(ciphertext []byte, key []byte, nonce int64, offset int, newtext []byte) ([]byte, bool) {
+	if offset < 0 || offset > len(ciphertext) { return ciphertext, false }
+	output := AppendBytes([]byte{}, ciphertext[0:offset])
+	edited, _ := AES128CTRDecryptAt(newtext, key, nonce, offset)
+	output = AppendBytes(output, edited)
+	if offset + len(newtext) < len(ciphertext) {
+		output = AppendBytes(output, ciphertext[offset + len(newtext):])
+	}
+	return output, true
+}
+
+// CTR mode as used by GCM: the counter is the last 32 bits of the counter block and
+// is incremented as a big-endian integer ("inc32" in NIST SP 800-38D).
+func CTR32Encrypt(block cipher.Block, counterBlock []byte, plain []byte) []byte {
+	bs := block.BlockSize()
+	output := make([]byte, len(plain))
+	counter := AppendBytes([]byte{}, counterBlock)
+	keystream := make([]byte, bs)
+	for i := 0; i < len(plain); i += bs {
+		block.Encrypt(keystream, counter)
+		end := i + bs
+		if end > len(plain) { end = len(plain) }
+		for j := i; j < end; j++ {
+			output[j] = plain[j] ^ keystream[j - i]
+		}
+		c := binary.BigEndian.Uint32(counter[bs - 4:])
+		binary.BigEndian.PutUint32(counter[bs - 4:], c + 1)
+	}
+	return output
+}
+
+func CTR32Decrypt(block cipher.Block, counterBlock []byte, encrypted []byte) []byte {
+	return CTR32Encrypt(block, counterBlock, encrypted)
+}
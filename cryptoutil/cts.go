@@ -0,0 +1,114 @@
+package cryptoutil
+
+import (
+	"crypto/cipher"
+)
+
+// CBC with ciphertext stealing, as described in the addendum to NIST SP 800-38A.
+// Unlike regular CBC, no padding is needed and the ciphertext has the same length
+// as the plaintext. The plaintext must be at least one block long.
+//
+// The last partial block is padded with zeros and the whole thing is encrypted with
+// CBC. Since the last ciphertext block depends on all the bytes of the previous one,
+// the previous block can then be truncated. The three variants only differ in the order
+// of the last two blocks.
+
+const (
+	CTS_CS1 = 1 // C[n-1]* || C[n]
+	CTS_CS2 = 2 // Same as CS3, unless the last block is complete, in which case same as CS1 (i.e. regular CBC)
+	CTS_CS3 = 3 // C[n] || C[n-1]*, always swapped (used by Kerberos)
+)
+
+func cbcEncrypt(block cipher.Block, plain []byte, iv []byte) []byte {
+	bs := block.BlockSize()
+	output := make([]byte, len(plain))
+	previous := iv
+	for i := 0; i < len(plain); i += bs {
+		block.Encrypt(output[i:i+bs], RepeatingKeyXor(plain[i:i+bs], previous))
+		previous = output[i:i+bs]
+	}
+	return output
+}
+
+func cbcDecrypt(block cipher.Block, encrypted []byte, iv []byte) []byte {
+	bs := block.BlockSize()
+	output := make([]byte, len(encrypted))
+	previous := iv
+	for i := 0; i < len(encrypted); i += bs {
+		block.Decrypt(output[i:i+bs], encrypted[i:i+bs])
+		copy(output[i:i+bs], RepeatingKeyXor(output[i:i+bs], previous))
+		previous = encrypted[i:i+bs]
+	}
+	return output
+}
+
+// Tells whether the last two blocks are swapped for the given variant and data length.
+func ctsSwapped(variant int, length int, bs int) bool {
+	if length <= bs { return false }
+	if variant == CTS_CS3 { return true }
+	if variant == CTS_CS2 { return length % bs != 0 }
+	return false
+}
+
+func CBCCTSEncrypt(block cipher.Block, plain []byte, iv []byte, variant int) ([]byte, bool) {
+	bs := block.BlockSize()
+	if len(plain) < bs { return nil, false }
+
+	// Size of the last block, between 1 and bs
+	d := len(plain) % bs
+	if d == 0 { d = bs }
+
+	padded := AppendBytes([]byte{}, plain)
+	padded = AppendBytes(padded, FillBytes(0, bs - d))
+	encrypted := cbcEncrypt(block, padded, iv)
+	n := len(encrypted) / bs
+	if n == 1 { return encrypted, true }
+
+	// C[1] ... C[n-2]
+	output := AppendBytes([]byte{}, encrypted[0:(n - 2) * bs])
+	previousStolen := encrypted[(n - 2) * bs:(n - 2) * bs + d] // C[n-1]*
+	last := encrypted[(n - 1) * bs:] // C[n]
+	if ctsSwapped(variant, len(plain), bs) {
+		output = AppendBytes(output, last)
+		output = AppendBytes(output, previousStolen)
+	} else {
+		output = AppendBytes(output, previousStolen)
+		output = AppendBytes(output, last)
+	}
+	return output, true
+}
+
+func CBCCTSDecrypt(block cipher.Block, encrypted []byte, iv []byte, variant int) ([]byte, bool) {
+	bs := block.BlockSize()
+	if len(encrypted) < bs { return nil, false }
+
+	d := len(encrypted) % bs
+	if d == 0 { d = bs }
+	n := (len(encrypted) - d) / bs + 1
+	if n == 1 { return cbcDecrypt(block, encrypted, iv), true }
+
+	// Put the last two blocks back in CS1 order
+	start := (n - 2) * bs
+	var previousStolen, last []byte
+	if ctsSwapped(variant, len(encrypted), bs) {
+		last = encrypted[start:start + bs]
+		previousStolen = encrypted[start + bs:]
+	} else {
+		previousStolen = encrypted[start:start + d]
+		last = encrypted[start + d:]
+	}
+
+	// D(C[n]) = P[n] ^ C[n-1], and since P[n] ends with zeros, the end of D(C[n]) is the
+	// part of C[n-1] that was stolen.
+	decryptedLast := make([]byte, bs)
+	block.Decrypt(decryptedLast, last)
+	previous := AppendBytes([]byte{}, previousStolen)
+	previous = AppendBytes(previous, decryptedLast[d:])
+
+	// Now we can decrypt everything up to C[n-1] with regular CBC, and P[n]* = D(C[n])* ^ C[n-1]*
+	full := AppendBytes([]byte{}, encrypted[0:start])
+	full = AppendBytes(full, previous)
+	output := cbcDecrypt(block, full, iv)
+	output = AppendBytes(output, RepeatingKeyXor(decryptedLast[0:d], previousStolen))
+	return output, true
+}
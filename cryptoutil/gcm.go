@@ -0,0 +1,93 @@
+package cryptoutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"../gf128"
+)
+
+// AES-GCM, as defined in NIST SP 800-38D. The plaintext is encrypted with CTR mode and
+// authenticated with GHASH, a polynomial MAC evaluated at H = E(K, 0^128):
+//
+// tag = E(K, J0) + C1 * H^n + C2 * H^(n-1) + ... + Cn * H
+//
+// where C1 ... Cn are the additional data, ciphertext and length blocks.
+
+type GCM struct {
+	block cipher.Block
+	h gf128.Element
+}
+
+func NewGCM(key []byte) *GCM {
+	output := new(GCM)
+	output.block, _ = aes.NewCipher(key)
+	h := make([]byte, 16)
+	output.block.Encrypt(h, h)
+	output.h = gf128.NewElement(h)
+	return output
+}
+
+// Returns the blocks that are authenticated by GHASH: the additional data and ciphertext,
+// each padded with zeros to a multiple of 16 bytes, followed by their lengths in bits.
+func GHASHBlocks(additionalData []byte, ciphertext []byte) []gf128.Element {
+	var output []gf128.Element
+	for _, data := range [][]byte{additionalData, ciphertext} {
+		for i := 0; i < len(data); i += 16 {
+			block := make([]byte, 16)
+			copy(block, data[i:])
+			output = append(output, gf128.NewElement(block))
+		}
+	}
+	lengths := make([]byte, 16)
+	binary.BigEndian.PutUint64(lengths[0:8], uint64(len(additionalData)) * 8)
+	binary.BigEndian.PutUint64(lengths[8:16], uint64(len(ciphertext)) * 8)
+	return append(output, gf128.NewElement(lengths))
+}
+
+func GHASH(h gf128.Element, additionalData []byte, ciphertext []byte) gf128.Element {
+	output := gf128.Zero()
+	for _, block := range GHASHBlocks(additionalData, ciphertext) {
+		output = output.Add(block).Mul(h)
+	}
+	return output
+}
+
+// Returns the pre-counter block J0. For the usual 96-bit nonces, it's nonce || 0^31 || 1,
+// otherwise it's the GHASH of the nonce.
+func (this *GCM) counterBlock(nonce []byte) []byte {
+	if len(nonce) == 12 {
+		return AppendBytes(AppendBytes([]byte{}, nonce), []byte{0, 0, 0, 1})
+	}
+	return GHASH(this.h, nil, nonce).Bytes()
+}
+
+func (this *GCM) tag(j0 []byte, additionalData []byte, ciphertext []byte) []byte {
+	s := make([]byte, 16)
+	this.block.Encrypt(s, j0)
+	return GHASH(this.h, additionalData, ciphertext).Add(gf128.NewElement(s)).Bytes()
+}
+
+// Encrypts and authenticates the plaintext. Returns the ciphertext with the 16-byte tag appended.
+func (this *GCM) Seal(nonce []byte, plain []byte, additionalData []byte) []byte {
+	j0 := this.counterBlock(nonce)
+	counter := AppendBytes([]byte{}, j0)
+	binary.BigEndian.PutUint32(counter[12:], binary.BigEndian.Uint32(counter[12:]) + 1)
+	ciphertext := CTR32Encrypt(this.block, counter, plain)
+	return AppendBytes(ciphertext, this.tag(j0, additionalData, ciphertext))
+}
+
+// Checks the tag and decrypts the ciphertext. Returns `false` if the tag is invalid.
+func (this *GCM) Open(nonce []byte, sealed []byte, additionalData []byte) ([]byte, bool) {
+	if len(sealed) < 16 { return nil, false }
+	ciphertext := sealed[0:len(sealed) - 16]
+	j0 := this.counterBlock(nonce)
+	// The tag must be compared in constant time, otherwise the time it takes to reject
+	// a forgery tells how many of its first bytes are right.
+	if !ConstantTimeEquals(this.tag(j0, additionalData, ciphertext), sealed[len(sealed) - 16:]) {
+		return nil, false
+	}
+	counter := AppendBytes([]byte{}, j0)
+	binary.BigEndian.PutUint32(counter[12:], binary.BigEndian.Uint32(counter[12:]) + 1)
+	return CTR32Decrypt(this.block, counter, ciphertext), true
+}
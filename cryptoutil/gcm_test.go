@@ -0,0 +1,35 @@
+package cryptoutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+)
+
+// Cross-check against crypto/cipher's GCM
+func TestGCM(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	block, _ := aes.NewCipher(key)
+	gcm := NewGCM(key)
+	for _, nonceSize := range []int{12, 8, 16} {
+		reference, _ := cipher.NewGCMWithNonceSize(block, nonceSize)
+		for length := 0; length < 50; length += 7 {
+			nonce := RandomBytes(nonceSize)
+			plain := RandomBytes(length)
+			additionalData := RandomBytes(length / 2)
+			expected := reference.Seal(nil, nonce, plain, additionalData)
+			sealed := gcm.Seal(nonce, plain, additionalData)
+			if !SliceEquals(sealed, expected) {
+				t.Errorf("nonce size %d, length %d: %x is different from %x", nonceSize, length, sealed, expected)
+			}
+			opened, ok := gcm.Open(nonce, sealed, additionalData)
+			if !ok || !SliceEquals(opened, plain) {
+				t.Errorf("nonce size %d, length %d: could not open %x", nonceSize, length, sealed)
+			}
+			sealed[0] ^= 1
+			if _, ok := gcm.Open(nonce, sealed, additionalData); ok {
+				t.Errorf("nonce size %d, length %d: modified ciphertext was accepted", nonceSize, length)
+			}
+		}
+	}
+}
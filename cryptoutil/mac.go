@@ -0,0 +1,14 @@
+package cryptoutil
+
+import (
+	"hash"
+)
+
+// Secret-prefix MAC: hash(key || message). This is vulnerable to length extension
+// with Merkle–Damgård hashes such as SHA-1, SHA-256 or MD4 (see q29).
+func SecretPrefixMAC(h hash.Hash, key []byte, message []byte) []byte {
+	h.Reset()
+	h.Write(key)
+	h.Write(message)
+	return h.Sum(nil)
+}
@@ -0,0 +1,155 @@
+package cryptoutil
+
+import (
+	"crypto/cipher"
+)
+
+// Additional block cipher modes, implemented on top of any cipher.Block.
+// See NIST SP 800-38A for OFB and CFB.
+
+// OFB mode. The keystream is obtained by repeatedly encrypting the IV:
+// O1 = E(IV), O2 = E(O1), etc. and the plaintext is XORed against it.
+// Encryption and decryption are the same operation.
+func OFBEncrypt(block cipher.Block, plain []byte, iv []byte) []byte {
+	bs := block.BlockSize()
+	output := make([]byte, len(plain))
+	keystream := AppendBytes([]byte{}, iv)
+	for i := 0; i < len(plain); i += bs {
+		block.Encrypt(keystream, keystream)
+		end := i + bs
+		if end > len(plain) { end = len(plain) }
+		for j := i; j < end; j++ {
+			output[j] = plain[j] ^ keystream[j - i]
+		}
+	}
+	return output
+}
+
+func OFBDecrypt(block cipher.Block, encrypted []byte, iv []byte) []byte {
+	return OFBEncrypt(block, encrypted, iv)
+}
+
+// CFB mode with full-block segments (CFB-128 for AES). Each ciphertext block
+// is C[i] = P[i] ^ E(C[i-1]), with C[0] = IV. The last segment can be partial.
+func CFB128Encrypt(block cipher.Block, plain []byte, iv []byte) []byte {
+	return cfbFullBlock(block, plain, iv, false)
+}
+
+func CFB128Decrypt(block cipher.Block, encrypted []byte, iv []byte) []byte {
+	return cfbFullBlock(block, encrypted, iv, true)
+}
+
+func cfbFullBlock(block cipher.Block, input []byte, iv []byte, decrypt bool) []byte {
+	bs := block.BlockSize()
+	output := make([]byte, len(input))
+	previous := AppendBytes([]byte{}, iv)
+	keystream := make([]byte, bs)
+	for i := 0; i < len(input); i += bs {
+		block.Encrypt(keystream, previous)
+		end := i + bs
+		if end > len(input) { end = len(input) }
+		for j := i; j < end; j++ {
+			output[j] = input[j] ^ keystream[j - i]
+		}
+		// The next block is always chained from the ciphertext
+		if decrypt {
+			copy(previous, input[i:end])
+		} else {
+			copy(previous, output[i:end])
+		}
+	}
+	return output
+}
+
+// CFB mode with 8-bit segments. The input register starts as the IV. For each
+// byte, the register is encrypted and the first byte of the result is XORed with
+// the plaintext byte. The register is then shifted left by one byte and the
+// ciphertext byte is appended to it. This requires one block encryption per byte.
+func CFB8Encrypt(block cipher.Block, plain []byte, iv []byte) []byte {
+	return cfb8(block, plain, iv, false)
+}
+
+func CFB8Decrypt(block cipher.Block, encrypted []byte, iv []byte) []byte {
+	return cfb8(block, encrypted, iv, true)
+}
+
+func cfb8(block cipher.Block, input []byte, iv []byte, decrypt bool) []byte {
+	bs := block.BlockSize()
+	output := make([]byte, len(input))
+	register := AppendBytes([]byte{}, iv)
+	keystream := make([]byte, bs)
+	for i := 0; i < len(input); i++ {
+		block.Encrypt(keystream, register)
+		output[i] = input[i] ^ keystream[0]
+		var c byte
+		if decrypt {
+			c = input[i]
+		} else {
+			c = output[i]
+		}
+		copy(register, register[1:])
+		register[bs - 1] = c
+	}
+	return output
+}
+
+// PCBC mode. Each plaintext block is XORed with both the previous plaintext
+// and previous ciphertext blocks before being encrypted:
+// C[i] = E(P[i] ^ P[i-1] ^ C[i-1]), with P[0] ^ C[0] = IV.
+// The data must be a multiple of the block size (i.e. already padded).
+func PCBCEncrypt(block cipher.Block, plain []byte, iv []byte) []byte {
+	bs := block.BlockSize()
+	output := make([]byte, len(plain))
+	previous := AppendBytes([]byte{}, iv) // P[i-1] ^ C[i-1]
+	for i := 0; i < len(plain); i += bs {
+		block.Encrypt(output[i:i+bs], RepeatingKeyXor(plain[i:i+bs], previous))
+		previous = RepeatingKeyXor(plain[i:i+bs], output[i:i+bs])
+	}
+	return output
+}
+
+func PCBCDecrypt(block cipher.Block, encrypted []byte, iv []byte) []byte {
+	bs := block.BlockSize()
+	output := make([]byte, len(encrypted))
+	previous := AppendBytes([]byte{}, iv)
+	for i := 0; i < len(encrypted); i += bs {
+		block.Decrypt(output[i:i+bs], encrypted[i:i+bs])
+		copy(output[i:i+bs], RepeatingKeyXor(output[i:i+bs], previous))
+		previous = RepeatingKeyXor(output[i:i+bs], encrypted[i:i+bs])
+	}
+	return output
+}
+
+// Returns the number of bytes at the start of the two ciphertexts that are identical.
+//
+// With CBC and CFB, if two messages are encrypted with the same key and IV, the
+// ciphertexts are identical for as long as the plaintexts are identical. So this
+// leaks the length of the common prefix of the plaintexts. It is rounded down to
+// the block size for CBC, but is exact for CFB since the plaintext is XORed directly
+// into the ciphertext.
+func CommonPrefixLength(ciphertext1 []byte, ciphertext2 []byte) int {
+	i := 0
+	for i < len(ciphertext1) && i < len(ciphertext2) && ciphertext1[i] == ciphertext2[i] {
+		i++
+	}
+	return i
+}
+
+// Tells whether two ciphertexts appear to be encrypted with the same keystream, as
+// happens with OFB (or CTR) when the key and IV are reused.
+//
+// In that case, c1 ^ c2 = p1 ^ p2, so if the plaintexts are ASCII text, the top bit
+// of every byte of c1 ^ c2 is 0. With distinct keystreams, each top bit is random so
+// the chance of that happening by accident is 1 / 2^n.
+func IsKeystreamReused(ciphertext1 []byte, ciphertext2 []byte) bool {
+	length := len(ciphertext1)
+	if len(ciphertext2) < length { length = len(ciphertext2) }
+	if length < 16 { return false }
+
+	for i := 0; i < length; i++ {
+		if (ciphertext1[i] ^ ciphertext2[i]) & 0x80 != 0 {
+			return false
+		}
+	}
+	return true
+}
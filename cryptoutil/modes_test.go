@@ -0,0 +1,200 @@
+package cryptoutil
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+)
+
+// Test vectors from NIST SP 800-38A, appendix F.
+
+var nistKey, _ = hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+var nistIv, _ = hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+var nistPlaintext, _ = hex.DecodeString(
+	"6bc1bee22e409f96e93d7e117393172a" +
+	"ae2d8a571e03ac9c9eb76fac45af8e51" +
+	"30c81c46a35ce411e5fbc1191a0a52ef" +
+	"f69f2445df4f9b17ad2b417be66c3710")
+
+type modeFunc func(b []byte) []byte
+
+func checkMode(t *testing.T, name string, encrypt modeFunc, decrypt modeFunc, plain []byte, expectedHex string) {
+	expected, _ := hex.DecodeString(expectedHex)
+	enc := encrypt(plain)
+	if !SliceEquals(enc, expected) {
+		t.Errorf("%s: %x is different from %x", name, enc, expected)
+	}
+	dec := decrypt(enc)
+	if !SliceEquals(dec, plain) {
+		t.Errorf("%s: %x is different from %x", name, dec, plain)
+	}
+}
+
+func TestOFB(t *testing.T) {
+	block, _ := aes.NewCipher(nistKey)
+	checkMode(t, "OFB",
+		func(b []byte) []byte { return OFBEncrypt(block, b, nistIv) },
+		func(b []byte) []byte { return OFBDecrypt(block, b, nistIv) },
+		nistPlaintext,
+		"3b3fd92eb72dad20333449f8e83cfb4a" +
+		"7789508d16918f03f53c52dac54ed825" +
+		"9740051e9c5fecf64344f7a82260edcc" +
+		"304c6528f659c77866a510d9c1d6ae5e")
+}
+
+func TestCFB128(t *testing.T) {
+	block, _ := aes.NewCipher(nistKey)
+	checkMode(t, "CFB128",
+		func(b []byte) []byte { return CFB128Encrypt(block, b, nistIv) },
+		func(b []byte) []byte { return CFB128Decrypt(block, b, nistIv) },
+		nistPlaintext,
+		"3b3fd92eb72dad20333449f8e83cfb4a" +
+		"c8a64537a0b3a93fcde3cdad9f1ce58b" +
+		"26751f67a3cbb140b1808cf187a4f4df" +
+		"c04b05357c5d1c0eeac4c66f9ff7f2e6")
+}
+
+func TestCFB8(t *testing.T) {
+	block, _ := aes.NewCipher(nistKey)
+	checkMode(t, "CFB8",
+		func(b []byte) []byte { return CFB8Encrypt(block, b, nistIv) },
+		func(b []byte) []byte { return CFB8Decrypt(block, b, nistIv) },
+		nistPlaintext[0:18],
+		"3b79424c9c0dd436bace9e0ed4586a4f32b9")
+}
+
+func TestPCBC(t *testing.T) {
+	block, _ := aes.NewCipher(nistKey)
+	enc := PCBCEncrypt(block, nistPlaintext, nistIv)
+	dec := PCBCDecrypt(block, enc, nistIv)
+	if !SliceEquals(dec, nistPlaintext) {
+		t.Errorf("%x is different from %x", dec, nistPlaintext)
+	}
+
+	// The first block is the same as CBC, but then errors propagate to all the following blocks
+	cbc := AES128CBCEncrypt(nistPlaintext, nistKey, nistIv)
+	if !SliceEquals(enc[0:16], cbc[0:16]) || SliceEquals(enc[16:32], cbc[16:32]) {
+		t.Errorf("unexpected PCBC ciphertext: %x", enc)
+	}
+	enc[20] ^= 1
+	dec = PCBCDecrypt(block, enc, nistIv)
+	if SliceEquals(dec[48:64], nistPlaintext[48:64]) {
+		t.Errorf("error did not propagate to the last block")
+	}
+}
+
+func TestLeakHelpers(t *testing.T) {
+	block, _ := aes.NewCipher(nistKey)
+	p1 := []byte("Attack at dawn, bring the ladders and the ropes")
+	p2 := []byte("Attack at dusk, bring the boats and the paddles")
+
+	if !IsKeystreamReused(OFBEncrypt(block, p1, nistIv), OFBEncrypt(block, p2, nistIv)) {
+		t.Errorf("keystream reuse not detected")
+	}
+	if IsKeystreamReused(OFBEncrypt(block, p1, nistIv), OFBEncrypt(block, p2, nistPlaintext[0:16])) {
+		t.Errorf("keystream reuse wrongly detected")
+	}
+
+	if n := CommonPrefixLength(CFB8Encrypt(block, p1, nistIv), CFB8Encrypt(block, p2, nistIv)); n != 11 {
+		t.Errorf("CFB8 common prefix: expected 11, got %d", n)
+	}
+	if n := CommonPrefixLength(CFB128Encrypt(block, p1, nistIv), CFB128Encrypt(block, p2, nistIv)); n != 11 {
+		t.Errorf("CFB128 common prefix: expected 11, got %d", n)
+	}
+}
+
+func TestCBCCTS(t *testing.T) {
+	// Test vectors from RFC 3962 (Kerberos), which uses CS3 with a zero IV
+	key, _ := hex.DecodeString("636869636b656e207465726979616b69")
+	block, _ := aes.NewCipher(key)
+	iv := make([]byte, 16)
+	plain, _ := hex.DecodeString("4920776f756c64206c696b65207468652047656e6572616c20476175277320436869636b656e2c20706c656173652c")
+	vectors := []struct {
+		length int
+		expected string
+	}{
+		{17, "c6353568f2bf8cb4d8a580362da7ff7f97"},
+		{31, "fc00783e0efdb2c1d445d4c8eff7ed2297687268d6ecccc0c07b25e25ecfe5"},
+		{32, "39312523a78662d5be7fcbcc98ebf5a897687268d6ecccc0c07b25e25ecfe584"},
+		{47, "97687268d6ecccc0c07b25e25ecfe584b3fffd940c16a18c1b5549d2f838029e39312523a78662d5be7fcbcc98ebf5"},
+	}
+	for _, v := range vectors {
+		checkMode(t, "CS3",
+			func(b []byte) []byte { r, _ := CBCCTSEncrypt(block, b, iv, CTS_CS3); return r },
+			func(b []byte) []byte { r, _ := CBCCTSDecrypt(block, b, iv, CTS_CS3); return r },
+			plain[0:v.length], v.expected)
+	}
+
+	// CS1 and CS2 give the same result as CBC when the data is a multiple of the block size
+	for _, variant := range []int{CTS_CS1, CTS_CS2} {
+		enc, _ := CBCCTSEncrypt(block, plain[0:32], iv, variant)
+		if !SliceEquals(enc, AES128CBCEncrypt(plain[0:32], key, iv)) {
+			t.Errorf("CS%d: unexpected ciphertext %x", variant, enc)
+		}
+	}
+
+	// Round trip for all variants and lengths
+	for _, variant := range []int{CTS_CS1, CTS_CS2, CTS_CS3} {
+		for length := 16; length <= len(plain); length++ {
+			enc, _ := CBCCTSEncrypt(block, plain[0:length], iv, variant)
+			dec, _ := CBCCTSDecrypt(block, enc, iv, variant)
+			if len(enc) != length || !SliceEquals(dec, plain[0:length]) {
+				t.Errorf("CS%d: round trip failed for length %d", variant, length)
+			}
+		}
+	}
+
+	if _, ok := CBCCTSEncrypt(block, plain[0:15], iv, CTS_CS1); ok {
+		t.Errorf("data shorter than a block should not be accepted")
+	}
+}
+
+func TestXTS(t *testing.T) {
+	// Test vectors from IEEE 1619
+	key := make([]byte, 32)
+	checkMode(t, "XTS",
+		func(b []byte) []byte { r, _ := XTSEncrypt(key, b, 0); return r },
+		func(b []byte) []byte { r, _ := XTSDecrypt(key, b, 0); return r },
+		make([]byte, 32),
+		"917cf69ebd68b2ec9b9fe9a3eadda692cd43d2f59598ed858c02c2652fbf922e")
+
+	// With ciphertext stealing
+	key, _ = hex.DecodeString("fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0")
+	plain, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f10")
+	checkMode(t, "XTS",
+		func(b []byte) []byte { r, _ := XTSEncrypt(key, b, 0x123456789a); return r },
+		func(b []byte) []byte { r, _ := XTSDecrypt(key, b, 0x123456789a); return r },
+		plain,
+		"6c1625db4671522d3d7599601de7ca09ed")
+}
+
+func TestXTSLeakHelpers(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	sectorSize := 64
+
+	// A disk image full of zeros, correctly encrypted, doesn't have any repeated block
+	var image []byte
+	var brokenImage []byte
+	for sector := 0; sector < 4; sector++ {
+		enc, _ := XTSEncrypt(key, make([]byte, sectorSize), uint64(sector))
+		image = AppendBytes(image, enc)
+		enc, _ = XTSEncrypt(key, make([]byte, sectorSize), 0)
+		brokenImage = AppendBytes(brokenImage, enc)
+	}
+	if IsECBEncrypted(image) || IsXTSTweakReused(image, sectorSize) {
+		t.Errorf("tweak reuse wrongly detected")
+	}
+	if !IsXTSTweakReused(brokenImage, sectorSize) {
+		t.Errorf("tweak reuse not detected")
+	}
+
+	// Modifying a sector only changes the blocks that have been modified
+	plain := make([]byte, sectorSize)
+	enc1, _ := XTSEncrypt(key, plain, 7)
+	plain[40] = 1
+	enc2, _ := XTSEncrypt(key, plain, 7)
+	changed := XTSChangedBlocks(enc1, enc2)
+	if len(changed) != 1 || changed[0] != 2 {
+		t.Errorf("unexpected changed blocks: %v", changed)
+	}
+}
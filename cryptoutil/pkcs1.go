@@ -0,0 +1,62 @@
+package cryptoutil
+
+import (
+	"crypto/rand"
+)
+
+// PKCS#1 v1.5 encryption padding (RFC 8017, section 7.2):
+//
+// EM = 0x00 || 0x02 || PS || 0x00 || M
+//
+// where PS is at least 8 random non-zero bytes and EM is k bytes long, k being the
+// size of the RSA modulus in bytes. Returns `false` if the message is too long.
+func Pkcs1v15Padding(message []byte, k int) ([]byte, bool) {
+	if len(message) > k - 11 { return nil, false }
+	output := []byte{0x00, 0x02}
+	for len(output) < k - len(message) - 1 {
+		b := make([]byte, 1)
+		rand.Read(b)
+		if b[0] != 0 {
+			output = append(output, b[0])
+		}
+	}
+	output = append(output, 0x00)
+	return AppendBytes(output, message), true
+}
+
+// Tells whether the data starts with 0x00 0x02. This is the (sloppy) check done by many
+// implementations, and all that is needed for Bleichenbacher's attack.
+func IsPkcs1v15Conforming(data []byte, k int) bool {
+	return len(data) == k && data[0] == 0x00 && data[1] == 0x02
+}
+
+// Checks the whole padding and returns the message
+func RemovePkcs1v15Padding(data []byte, k int) ([]byte, bool) {
+	if !IsPkcs1v15Conforming(data, k) { return nil, false }
+	for i := 2; i < len(data); i++ {
+		if data[i] == 0 {
+			if i < 10 { return nil, false }
+			return data[i + 1:], true
+		}
+	}
+	return nil, false
+}
+
+// ASN.1 DER encoding of the DigestInfo structure, without the digest itself, which is
+// appended at the end (RFC 8017, section 9.2).
+var DigestInfoSHA1 = []byte{0x30, 0x21, 0x30, 0x09, 0x06, 0x05, 0x2b, 0x0e, 0x03, 0x02, 0x1a, 0x05, 0x00, 0x04, 0x14}
+var DigestInfoSHA256 = []byte{0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20}
+
+// PKCS#1 v1.5 signature padding:
+//
+// EM = 0x00 || 0x01 || 0xff ... 0xff || 0x00 || DigestInfo || digest
+//
+// Returns `false` if k is too small.
+func Pkcs1v15SignaturePadding(digestInfo []byte, digest []byte, k int) ([]byte, bool) {
+	t := AppendBytes(AppendBytes([]byte{}, digestInfo), digest)
+	if len(t) > k - 11 { return nil, false }
+	output := []byte{0x00, 0x01}
+	output = AppendBytes(output, FillBytes(0xff, k - len(t) - 3))
+	output = append(output, 0x00)
+	return AppendBytes(output, t), true
+}
@@ -0,0 +1,147 @@
+package cryptoutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+)
+
+// XTS-AES, as defined in IEEE 1619 and used for disk encryption. The key is the
+// concatenation of two AES keys: key1 encrypts the data and key2 encrypts the sector
+// number to create the tweak. Each block j of a sector is encrypted as:
+//
+// T = E(key2, sector) * alpha^j
+// C = E(key1, P ^ T) ^ T
+//
+// Within a sector, identical blocks at different positions give different ciphertexts,
+// and the same block at the same position in different sectors too. However, the same
+// plaintext at the same position in the same sector always gives the same ciphertext.
+// A partial last block is handled with ciphertext stealing.
+
+// Multiplies the tweak by alpha (i.e. x) in GF(2^128), with the bytes in little-endian order.
+func xtsMulAlpha(tweak []byte) {
+	carry := tweak[15] >> 7
+	for i := 15; i > 0; i-- {
+		tweak[i] = tweak[i] << 1 | tweak[i - 1] >> 7
+	}
+	tweak[0] <<= 1
+	if carry != 0 {
+		tweak[0] ^= 0x87
+	}
+}
+
+func xtsCiphers(key []byte) (cipher.Block, cipher.Block, bool) {
+	if len(key) != 32 && len(key) != 64 { return nil, nil, false }
+	cypher1, _ := aes.NewCipher(key[0:len(key) / 2])
+	cypher2, _ := aes.NewCipher(key[len(key) / 2:])
+	return cypher1, cypher2, true
+}
+
+// Returns the tweak for the first block of the sector
+func xtsTweak(cypher2 cipher.Block, sector uint64) []byte {
+	tweak := make([]byte, 16)
+	binary.LittleEndian.PutUint64(tweak, sector)
+	cypher2.Encrypt(tweak, tweak)
+	return tweak
+}
+
+func xtsBlock(cypher cipher.Block, input []byte, tweak []byte, decrypt bool) []byte {
+	output := RepeatingKeyXor(input, tweak)
+	if decrypt {
+		cypher.Decrypt(output, output)
+	} else {
+		cypher.Encrypt(output, output)
+	}
+	return RepeatingKeyXor(output, tweak)
+}
+
+// Encrypts one sector (data unit). The data must be at least one block long.
+func XTSEncrypt(key []byte, plain []byte, sector uint64) ([]byte, bool) {
+	return xts(key, plain, sector, false)
+}
+
+func XTSDecrypt(key []byte, encrypted []byte, sector uint64) ([]byte, bool) {
+	return xts(key, encrypted, sector, true)
+}
+
+func xts(key []byte, input []byte, sector uint64, decrypt bool) ([]byte, bool) {
+	bs := 16
+	cypher1, cypher2, ok := xtsCiphers(key)
+	if !ok || len(input) < bs { return nil, false }
+
+	output := make([]byte, len(input))
+	tweak := xtsTweak(cypher2, sector)
+	fullBlocks := len(input) / bs
+	d := len(input) % bs
+	if d != 0 {
+		// The last full block is handled with the partial block below
+		fullBlocks--
+	}
+	for j := 0; j < fullBlocks; j++ {
+		copy(output[j * bs:], xtsBlock(cypher1, input[j * bs:(j + 1) * bs], tweak, decrypt))
+		xtsMulAlpha(tweak)
+	}
+	if d == 0 {
+		return output, true
+	}
+
+	// Ciphertext stealing. When encrypting, the last full block is encrypted with the
+	// current tweak, then its end is appended to the partial block, which is encrypted
+	// with the next tweak. When decrypting the tweaks are used in reverse order.
+	m := fullBlocks * bs
+	tweak1 := AppendBytes([]byte{}, tweak)
+	tweak2 := AppendBytes([]byte{}, tweak)
+	xtsMulAlpha(tweak2)
+	if decrypt {
+		tweak1, tweak2 = tweak2, tweak1
+	}
+	cc := xtsBlock(cypher1, input[m:m + bs], tweak1, decrypt)
+	pp := AppendBytes([]byte{}, input[m + bs:])
+	pp = AppendBytes(pp, cc[d:])
+	copy(output[m:], xtsBlock(cypher1, pp, tweak2, decrypt))
+	copy(output[m + bs:], cc[0:d])
+	return output, true
+}
+
+// Given two versions of the same encrypted sector (for example from two snapshots of
+// a disk image), returns the indexes of the blocks that have changed. Since the tweak
+// only depends on the sector and block position, any block that hasn't changed in the
+// ciphertext hasn't changed in the plaintext either.
+func XTSChangedBlocks(ciphertext1 []byte, ciphertext2 []byte) []int {
+	bs := 16
+	var output []int
+	for i := 0; i * bs < len(ciphertext1) || i * bs < len(ciphertext2); i++ {
+		if (i + 1) * bs > len(ciphertext1) || (i + 1) * bs > len(ciphertext2) {
+			output = append(output, i)
+			continue
+		}
+		if !SliceEquals(ciphertext1[i * bs:(i + 1) * bs], ciphertext2[i * bs:(i + 1) * bs]) {
+			output = append(output, i)
+		}
+	}
+	return output
+}
+
+// Tells whether a disk image appears to have been encrypted with the same tweak for
+// several sectors (e.g. a broken implementation that doesn't pass the sector number).
+// Like IsECBEncrypted, this relies on the fact that disk images usually contain many
+// identical blocks (typically zeros). With a correct tweak, these identical blocks give
+// different ciphertexts in each sector, but if the tweak is reused, the same block at the
+// same position in two sectors gives the same ciphertext.
+func IsXTSTweakReused(data []byte, sectorSize int) bool {
+	bs := 16
+	if sectorSize < bs || sectorSize % bs != 0 { return false }
+	sectorCount := len(data) / sectorSize
+	for offset := 0; offset < sectorSize; offset += bs {
+		for i := 0; i < sectorCount - 1; i++ {
+			slice1 := data[i * sectorSize + offset:i * sectorSize + offset + bs]
+			for j := i + 1; j < sectorCount; j++ {
+				slice2 := data[j * sectorSize + offset:j * sectorSize + offset + bs]
+				if SliceEquals(slice1, slice2) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package dh
+
+import (
+	"crypto/rand"
+	"math/big"
+	"../mdhash"
+)
+
+// Diffie-Hellman key exchange over the integers modulo a prime.
+
+// The 1536-bit MODP group from RFC 3526 (the "NIST prime")
+var P, _ = new(big.Int).SetString(
+	"ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024" +
+	"e088a67cc74020bbea63b139b22514a08798e3404ddef9519b3cd" +
+	"3a431b302b0a6df25f14374fe1356d6d51c245e485b576625e7ec" +
+	"6f44c42e9a637ed6b0bff5cb6f406b7edee386bfb5a899fa5ae9f" +
+	"24117c4b1fe649286651ece45b3dc2007cb8a163bf0598da48361" +
+	"c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552" +
+	"bb9ed529077096966d670c354e4abc9804f1746c08ca237327fff" +
+	"fffffffffffff", 16)
+
+var G = big.NewInt(2)
+
+type KeyPair struct {
+	Private *big.Int
+	Public *big.Int
+}
+
+// Generates a random private key a in [1, p - 1) and the public key A = g^a mod p
+func GenerateKey(p *big.Int, g *big.Int) *KeyPair {
+	output := new(KeyPair)
+	max := new(big.Int).Sub(p, big.NewInt(2))
+	output.Private, _ = rand.Int(rand.Reader, max)
+	output.Private.Add(output.Private, big.NewInt(1))
+	output.Public = new(big.Int).Exp(g, output.Private, p)
+	return output
+}
+
+// s = B^a mod p
+func SharedSecret(private *big.Int, public *big.Int, p *big.Int) *big.Int {
+	return new(big.Int).Exp(public, private, p)
+}
+
+// Derives an AES-128 key from the shared secret: SHA1(s)[0:16]
+func DeriveKey(secret *big.Int) []byte {
+	return mdhash.SHA1(secret.Bytes())[0:16]
+}
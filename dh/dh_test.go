@@ -0,0 +1,36 @@
+package dh
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestSharedSecret(t *testing.T) {
+	// Small parameters, as in the first part of challenge 33
+	p := big.NewInt(37)
+	g := big.NewInt(5)
+	a := GenerateKey(p, g)
+	b := GenerateKey(p, g)
+	if SharedSecret(a.Private, b.Public, p).Cmp(SharedSecret(b.Private, a.Public, p)) != 0 {
+		t.Errorf("secrets are different")
+	}
+
+	a = GenerateKey(P, G)
+	b = GenerateKey(P, G)
+	s := SharedSecret(a.Private, b.Public, P)
+	if s.Cmp(SharedSecret(b.Private, a.Public, P)) != 0 {
+		t.Errorf("secrets are different")
+	}
+	message, ok := DecryptMessage(s, EncryptMessage(s, []byte("YELLOW SUBMARINE")))
+	if !ok || string(message) != "YELLOW SUBMARINE" {
+		t.Errorf("could not decrypt message")
+	}
+}
+
+func TestProtocol(t *testing.T) {
+	connA, connB := Pipe()
+	go Bob(connB)
+	if !Alice(connA, P, G, [][]byte{[]byte("hello"), []byte("YELLOW SUBMARINE")}) {
+		t.Errorf("exchange failed")
+	}
+}
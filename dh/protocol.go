@@ -0,0 +1,134 @@
+package dh
+
+import (
+	"log"
+	"math/big"
+	"../cryptoutil"
+)
+
+// In-process simulation of a protocol where A and B agree on a group, exchange their
+// public keys, and then send each other AES-CBC encrypted messages keyed from the shared
+// secret. The network is a pair of channels, so a man in the middle can be inserted
+// simply by giving A and B a connection to it instead of to each other:
+//
+// A->B: negotiate p, g
+// B->A: ACK p, g
+// A->B: A
+// B->A: B
+// A->B: AES-CBC(SHA1(s)[0:16], iv=random, msg) + iv
+// B->A: AES-CBC(SHA1(s)[0:16], iv=random, A's msg) + iv
+
+const (
+	NEGOTIATE = 1
+	ACK = 2
+	KEY = 3
+	DATA = 4
+)
+
+type Message struct {
+	Type int
+	P *big.Int
+	G *big.Int
+	Key *big.Int
+	Data []byte
+}
+
+// One end of a network connection
+type Conn struct {
+	in <-chan *Message
+	out chan<- *Message
+}
+
+// Creates a connection and returns both of its ends
+func Pipe() (*Conn, *Conn) {
+	c1 := make(chan *Message)
+	c2 := make(chan *Message)
+	return &Conn{c1, c2}, &Conn{c2, c1}
+}
+
+func (this *Conn) Send(message *Message) {
+	this.out <- message
+}
+
+// Returns `false` if the other end has closed the connection
+func (this *Conn) Receive() (*Message, bool) {
+	message, ok := <-this.in
+	return message, ok
+}
+
+func (this *Conn) Close() {
+	close(this.out)
+}
+
+// Encrypts the message with the key derived from the secret. Returns ciphertext || iv.
+func EncryptMessage(secret *big.Int, message []byte) []byte {
+	iv := cryptoutil.RandomBytes(16)
+	padded := cryptoutil.AppendBytes([]byte{}, message)
+	padded = cryptoutil.Pkcs7padding(padded, len(padded) + cryptoutil.Pkcs7paddingCount(padded))
+	return cryptoutil.AppendBytes(cryptoutil.AES128CBCEncrypt(padded, DeriveKey(secret), iv), iv)
+}
+
+// Returns `false` if the padding is invalid, which usually means the secret is wrong.
+func DecryptMessage(secret *big.Int, data []byte) ([]byte, bool) {
+	if len(data) < 32 || len(data) % 16 != 0 { return nil, false }
+	ciphertext := data[0:len(data) - 16]
+	iv := data[len(data) - 16:]
+	return cryptoutil.ConstantTimeRemovePkcs7padding(cryptoutil.AES128CBCDecrypt(ciphertext, DeriveKey(secret), iv))
+}
+
+// Initiates the exchange with the given group, sends each message and checks that B
+// echoes it back. Returns `true` if all the messages were echoed correctly.
+func Alice(conn *Conn, p *big.Int, g *big.Int, messages [][]byte) bool {
+	defer conn.Close()
+
+	conn.Send(&Message{Type: NEGOTIATE, P: p, G: g})
+	ack, ok := conn.Receive()
+	if !ok || ack.Type != ACK { return false }
+
+	key := GenerateKey(p, g)
+	conn.Send(&Message{Type: KEY, Key: key.Public})
+	reply, ok := conn.Receive()
+	if !ok || reply.Type != KEY { return false }
+	secret := SharedSecret(key.Private, reply.Key, p)
+
+	for _, message := range messages {
+		conn.Send(&Message{Type: DATA, Data: EncryptMessage(secret, message)})
+		reply, ok := conn.Receive()
+		if !ok { return false }
+		echo, ok := DecryptMessage(secret, reply.Data)
+		if !ok || !cryptoutil.SliceEquals(echo, message) {
+			log.Println("A: invalid echo")
+			return false
+		}
+		log.Printf("A: echo received: %q", echo)
+	}
+	return true
+}
+
+// Accepts the group proposed by A, then echoes back all the messages it receives.
+func Bob(conn *Conn) {
+	defer conn.Close()
+
+	negotiate, ok := conn.Receive()
+	if !ok || negotiate.Type != NEGOTIATE { return }
+	p, g := negotiate.P, negotiate.G
+	conn.Send(&Message{Type: ACK, P: p, G: g})
+
+	public, ok := conn.Receive()
+	if !ok || public.Type != KEY { return }
+	key := GenerateKey(p, g)
+	conn.Send(&Message{Type: KEY, Key: key.Public})
+	secret := SharedSecret(key.Private, public.Key, p)
+
+	for {
+		data, ok := conn.Receive()
+		if !ok { return }
+		message, ok := DecryptMessage(secret, data.Data)
+		if !ok {
+			log.Println("B: could not decrypt message")
+			return
+		}
+		log.Printf("B: message received: %q", message)
+		conn.Send(&Message{Type: DATA, Data: EncryptMessage(secret, message)})
+	}
+}
@@ -0,0 +1,113 @@
+package dlog
+
+import (
+	"math/big"
+	"../numutil"
+)
+
+// Discrete logarithms in the multiplicative group modulo a prime p: given g and y = g^x mod p,
+// find x. In general this is hard, but it becomes practical when the order of g only has small
+// prime factors (Pohlig-Hellman), or when x is known to be in a small interval (baby-step
+// giant-step, Pollard's kangaroo). Pollard's rho works in any group in about sqrt(q) steps,
+// where q is the order of g.
+
+var zero = big.NewInt(0)
+var one = big.NewInt(1)
+
+// Used to index big integers in maps. Two different values can have the same key, so a
+// match must always be checked.
+func mapKey(n *big.Int) uint64 {
+	words := n.Bits()
+	if len(words) == 0 { return 0 }
+	return uint64(words[0])
+}
+
+// Finds x in [a, b] such that g^x = y mod p, with about 2 * sqrt(b - a) multiplications and
+// sqrt(b - a) values in memory. Returns `false` if there is no such x.
+//
+// With m = ceil(sqrt(b - a + 1)), x - a = i * m + j with 0 <= i, j < m, so y * g^-a * g^(-m * i) = g^j.
+// We store the baby steps g^j in a table, and take giant steps of g^-m from y * g^-a until we
+// find one of them.
+func BabyStepGiantStep(g *big.Int, y *big.Int, p *big.Int, a *big.Int, b *big.Int) (*big.Int, bool) {
+	n := new(big.Int).Sub(b, a)
+	if n.Sign() < 0 { return nil, false }
+	m := new(big.Int).Sqrt(n)
+	m.Add(m, one)
+	if !m.IsInt64() { return nil, false }
+	steps := m.Int64()
+
+	baby := make(map[uint64][]int64)
+	pt := big.NewInt(1)
+	for j := int64(0); j < steps; j++ {
+		baby[mapKey(pt)] = append(baby[mapKey(pt)], j)
+		pt.Mul(pt, g)
+		pt.Mod(pt, p)
+	}
+
+	// g^-m = (g^m)^-1
+	step := new(big.Int).ModInverse(pt, p)
+	if step == nil { return nil, false }
+	z := new(big.Int).Exp(g, a, p)
+	z.ModInverse(z, p)
+	z.Mul(z, y)
+	z.Mod(z, p)
+	for i := int64(0); i < steps; i++ {
+		for _, j := range baby[mapKey(z)] {
+			x := big.NewInt(i)
+			x.Mul(x, m)
+			x.Add(x, big.NewInt(j))
+			x.Add(x, a)
+			if x.Cmp(b) <= 0 && new(big.Int).Exp(g, x, p).Cmp(y) == 0 { return x, true }
+		}
+		z.Mul(z, step)
+		z.Mod(z, p)
+	}
+	return nil, false
+}
+
+// Pohlig-Hellman: finds x such that g^x = y mod p, where n is the order of g and only has
+// prime factors smaller than bound. For each prime power r^e dividing n, the log in the
+// subgroup of order r^e is found one base-r digit at a time, with baby-step giant-step in the
+// subgroup of order r. The results are combined with the CRT.
+// Returns `false` if n has a larger factor, or if y is not a power of g.
+func PohligHellman(g *big.Int, y *big.Int, p *big.Int, n *big.Int, bound int64) (*big.Int, bool) {
+	var residues []*big.Int
+	var moduli []*big.Int
+	rest := new(big.Int).Set(n)
+	for _, r := range numutil.SmallFactors(n, bound) {
+		// r^e
+		re := big.NewInt(1)
+		e := 0
+		for new(big.Int).Mod(rest, r).Sign() == 0 {
+			rest.Div(rest, r)
+			re.Mul(re, r)
+			e++
+		}
+
+		// gr has order r. With x = x_0 + x_1 * r + ... mod r^e, each digit x_k is the log of
+		// (y * g^-(x mod r^k))^(n / r^(k+1)) to the base gr.
+		gr := new(big.Int).Exp(g, new(big.Int).Div(n, r), p)
+		x := big.NewInt(0)
+		rk := big.NewInt(1) // r^k
+		rMinusOne := new(big.Int).Sub(r, one)
+		for k := 0; k < e; k++ {
+			z := new(big.Int).Exp(g, x, p)
+			z.ModInverse(z, p)
+			z.Mul(z, y)
+			z.Mod(z, p)
+			exponent := new(big.Int).Div(n, new(big.Int).Mul(rk, r))
+			z.Exp(z, exponent, p)
+			digit, ok := BabyStepGiantStep(gr, z, p, zero, rMinusOne)
+			if !ok { return nil, false }
+			x.Add(x, digit.Mul(digit, rk))
+			rk.Mul(rk, r)
+		}
+		residues = append(residues, x)
+		moduli = append(moduli, re)
+	}
+	if rest.Cmp(one) != 0 { return nil, false }
+
+	x := numutil.CRT(residues, moduli)
+	if new(big.Int).Exp(g, x, p).Cmp(y) != 0 { return nil, false }
+	return x, true
+}
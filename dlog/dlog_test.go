@@ -0,0 +1,143 @@
+package dlog
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"runtime"
+	"testing"
+)
+
+func fromDec(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+// 512-bit p, with g of 128-bit prime order q
+var p58 = fromDec("11470374874925275658116663507232161402086650258453896274534991676898999262641581519101074740642369848233294239851519212341844337347119899874391456329785623")
+var q58 = fromDec("335062023296420808191071248367701059461")
+var g58 = fromDec("622952335333961296978159266084741085889881358738459939978290179936063635566740258555167783009058567397963466103140082647486611657350811560630587013183357")
+
+func TestParameters(t *testing.T) {
+	if new(big.Int).Exp(g58, q58, p58).Cmp(one) != 0 {
+		t.Errorf("g doesn't have order q")
+	}
+	if new(big.Int).Mod(new(big.Int).Sub(p58, one), q58).Sign() != 0 {
+		t.Errorf("q doesn't divide p - 1")
+	}
+}
+
+func randomInterval(bits int) (*big.Int, *big.Int, *big.Int) {
+	a, _ := rand.Int(rand.Reader, q58)
+	width := new(big.Int).Lsh(one, uint(bits))
+	offset, _ := rand.Int(rand.Reader, width)
+	return a, new(big.Int).Add(a, width), offset.Add(offset, a)
+}
+
+func TestBabyStepGiantStep(t *testing.T) {
+	a, b, x := randomInterval(24)
+	y := new(big.Int).Exp(g58, x, p58)
+	found, ok := BabyStepGiantStep(g58, y, p58, a, b)
+	if !ok || found.Cmp(x) != 0 {
+		t.Errorf("expected %v, got %v", x, found)
+	}
+	// Interval boundaries
+	for _, x := range []*big.Int{a, b} {
+		found, ok := BabyStepGiantStep(g58, new(big.Int).Exp(g58, x, p58), p58, a, b)
+		if !ok || found.Cmp(x) != 0 {
+			t.Errorf("expected %v, got %v", x, found)
+		}
+	}
+	if _, ok := BabyStepGiantStep(g58, new(big.Int).Exp(g58, new(big.Int).Add(b, one), p58), p58, a, b); ok {
+		t.Errorf("found a log outside of the interval")
+	}
+}
+
+func TestPohligHellman(t *testing.T) {
+	// p - 1 = 2^3 * 3^2 * 5 * 7 * 17 * 19 * 23 * 43 * 47 * 53 * 67 * 71 * 73 * 79 * 83 * 97 * 103,
+	// and 11 is a generator
+	p := fromDec("22809367407112203954379141")
+	g := big.NewInt(11)
+	n := new(big.Int).Sub(p, one)
+	for i := 0; i < 5; i++ {
+		x, _ := rand.Int(rand.Reader, n)
+		found, ok := PohligHellman(g, new(big.Int).Exp(g, x, p), p, n, 1000)
+		if !ok || found.Cmp(x) != 0 {
+			t.Errorf("expected %v, got %v", x, found)
+		}
+	}
+	if _, ok := PohligHellman(g58, g58, p58, q58, 1000); ok {
+		t.Errorf("order with a large factor accepted")
+	}
+}
+
+func TestPollardRho(t *testing.T) {
+	// g has 40-bit prime order q
+	p := fromDec("444814003976883585265929274354576552243")
+	q := fromDec("752975597887")
+	g := fromDec("27453751878451357770437732777711995959")
+	x, _ := rand.Int(rand.Reader, q)
+	found, ok := PollardRho(g, new(big.Int).Exp(g, x, p), p, q)
+	if !ok || found.Cmp(x) != 0 {
+		t.Errorf("expected %v, got %v", x, found)
+	}
+}
+
+func TestKangaroo(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		a, b, x := randomInterval(28)
+		found, ok := Kangaroo(g58, new(big.Int).Exp(g58, x, p58), p58, a, b, workers)
+		if !ok || found.Cmp(x) != 0 {
+			t.Errorf("%d workers: expected %v, got %v", workers, x, found)
+		}
+	}
+	a, b, _ := randomInterval(16)
+	if _, ok := Kangaroo(g58, new(big.Int).Exp(g58, new(big.Int).Add(b, big.NewInt(1 << 20)), p58), p58, a, b, 2); ok {
+		t.Errorf("found a log outside of the interval")
+	}
+}
+
+func TestSmallSubgroupAttack(t *testing.T) {
+	p := fromDec("7199773997391911030609999317773941274322764333428698921736339643928346453700085358802973900485592910475480089726140708102474957429903531369589969318716771")
+	q := fromDec("236234353446506858198510045061214171961")
+	x, _ := rand.Int(rand.Reader, q)
+	message := []byte("crazy flamboyant for the rap enjoyment")
+	mac := func(k *big.Int) []byte {
+		h := hmac.New(sha256.New, k.Bytes())
+		h.Write(message)
+		return h.Sum(nil)
+	}
+	oracle := func(h *big.Int) []byte {
+		return mac(new(big.Int).Exp(h, x, p))
+	}
+	check := func(k *big.Int, tag []byte) bool {
+		return hmac.Equal(mac(k), tag)
+	}
+	found, product := SmallSubgroupAttack(p, q, 1 << 16, oracle, check)
+	if product.Cmp(q) <= 0 || found.Cmp(x) != 0 {
+		t.Errorf("expected %v, got %v mod %v", x, found, product)
+	}
+}
+
+func benchmarkKangaroo(b *testing.B, bits int, workers int) {
+	for i := 0; i < b.N; i++ {
+		start, end, x := randomInterval(bits)
+		if _, ok := Kangaroo(g58, new(big.Int).Exp(g58, x, p58), p58, start, end, workers); !ok {
+			b.Fatal("log not found")
+		}
+	}
+}
+
+func BenchmarkKangaroo(b *testing.B) {
+	workerCounts := []int{1}
+	if n := runtime.NumCPU(); n > 1 { workerCounts = append(workerCounts, n) }
+	for _, bits := range []int{20, 25, 30, 35, 40} {
+		for _, workers := range workerCounts {
+			b.Run(fmt.Sprintf("%dbits/%dworkers", bits, workers), func(b *testing.B) {
+				benchmarkKangaroo(b, bits, workers)
+			})
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package dlog
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+)
+
+// Pollard's kangaroo (lambda) method: finds x in [a, b] such that g^x = y mod p, in about
+// 2 * sqrt(b - a) multiplications and little memory.
+//
+// A "tame" kangaroo starts from a known power of g in the middle of the interval and a "wild"
+// kangaroo starts from y. Each jumps forward by g^(2^i), with i a function of its current
+// position, so when one lands on a position visited by the other, it follows the same path
+// afterwards. We track the distance they travelled, and when they meet, the exponent of the tame
+// one is x plus the distance travelled by the wild one.
+//
+// This is the parallel version from van Oorschot and Wiener, "Parallel collision search with
+// cryptanalytic applications": each worker runs one tame and one wild kangaroo, and only the
+// "distinguished" positions (those with their low bits set to 0) are shared. A collision is
+// detected at the next distinguished position after the paths meet. Two kangaroos of the same
+// kind that meet are useless, so one of them is restarted.
+
+type kangaroo struct {
+	tame bool
+	start *big.Int // exponent for a tame kangaroo, offset from x for a wild one
+	distance uint64
+	position *big.Int
+}
+
+type trap struct {
+	tame bool
+	exponent *big.Int // start + distance
+}
+
+// Finds x in [a, b] such that g^x = y mod p, using the given number of goroutines.
+// Returns `false` if there is no such x (after a number of steps that makes the probability of
+// missing it negligible). The interval must be smaller than 2^100 or so for the distances to
+// fit in 64 bits, which is far beyond what is practical anyway.
+func Kangaroo(g *big.Int, y *big.Int, p *big.Int, a *big.Int, b *big.Int, workers int) (*big.Int, bool) {
+	n := new(big.Int).Sub(b, a)
+	if n.Sign() < 0 { return nil, false }
+	if workers < 1 { workers = 1 }
+	if n.Cmp(big.NewInt(16)) < 0 { return BabyStepGiantStep(g, y, p, a, b) }
+
+	// With m kangaroos in total, the mean jump should be about m * sqrt(n) / 4. The jumps are
+	// powers of two 2^0 ... 2^(k-1), with a mean of about 2^k / k.
+	root := new(big.Int).Sqrt(n)
+	mean := new(big.Int).Mul(root, big.NewInt(int64(2 * workers)))
+	mean.Rsh(mean, 2)
+	k := 1
+	for (uint64(1) << uint(k)) / uint64(k) < mean.Uint64() && k < 62 {
+		k++
+	}
+	jumps := make([]*big.Int, k)
+	for i := range jumps {
+		jumps[i] = new(big.Int).Exp(g, new(big.Int).Lsh(one, uint(i)), p)
+	}
+
+	// Each kangaroo should travel about sqrt(n) / (8 * workers) steps between two distinguished
+	// positions, so that it doesn't take long to notice a collision.
+	dpBits := uint(0)
+	for uint64(1) << (dpBits + 1) <= root.Uint64() / uint64(8 * workers) {
+		dpBits++
+	}
+	dpMask := uint64(1) << dpBits - 1
+
+	// Total number of steps per worker before giving up
+	limit := root.Uint64() / uint64(workers) * 32 + (dpMask + 1) * 64 + 1024
+
+	var mutex sync.Mutex
+	traps := make(map[string]trap)
+	var result *big.Int
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	spread := new(big.Int).Add(mean, one)
+	middle := new(big.Int).Rsh(n, 1)
+	middle.Add(middle, a)
+	restart := func(this *kangaroo) {
+		offset, _ := rand.Int(rand.Reader, spread)
+		this.distance = 0
+		if this.tame {
+			this.start = offset.Add(offset, middle)
+			this.position = new(big.Int).Exp(g, this.start, p)
+		} else {
+			this.start = offset
+			this.position = new(big.Int).Exp(g, offset, p)
+			this.position.Mul(this.position, y)
+			this.position.Mod(this.position, p)
+		}
+	}
+
+	// Records a distinguished position. Returns true when the log is found.
+	record := func(this *kangaroo) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if result != nil { return true }
+		exponent := new(big.Int).SetUint64(this.distance)
+		exponent.Add(exponent, this.start)
+		key := string(this.position.Bytes())
+		other, found := traps[key]
+		if !found {
+			traps[key] = trap{this.tame, exponent}
+			return false
+		}
+		if other.tame == this.tame {
+			restart(this)
+			return false
+		}
+		// tame exponent = x + wild exponent
+		x := new(big.Int)
+		if this.tame {
+			x.Sub(exponent, other.exponent)
+		} else {
+			x.Sub(other.exponent, exponent)
+		}
+		if x.Cmp(a) < 0 || x.Cmp(b) > 0 || new(big.Int).Exp(g, x, p).Cmp(y) != 0 { return false }
+		result = x
+		close(done)
+		return true
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			herd := []*kangaroo{{tame: true}, {tame: false}}
+			for _, this := range herd {
+				restart(this)
+			}
+			for steps := uint64(0); steps < limit; steps++ {
+				if steps & 1023 == 0 {
+					select {
+					case <-done:
+						return
+					default:
+					}
+				}
+				for _, this := range herd {
+					i := mapKey(this.position) % uint64(k)
+					this.position.Mul(this.position, jumps[i])
+					this.position.Mod(this.position, p)
+					this.distance += uint64(1) << i
+					if mapKey(this.position) & dpMask == 0 && record(this) { return }
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return result, result != nil
+}
@@ -0,0 +1,69 @@
+package dlog
+
+import (
+	"crypto/rand"
+	"math"
+	"math/big"
+)
+
+// Pollard's rho: finds x such that g^x = y mod p, where g has prime order q, in about
+// sqrt(q) steps and constant memory.
+//
+// We walk through values z = g^a * y^b with a pseudo-random function that depends on z, and
+// keeps track of a and b: z * y, z^2 or z * g depending on z mod 3. The walk eventually enters a
+// cycle, which Floyd's algorithm detects with a second walk going twice as fast. When the two
+// walks meet, g^a1 * y^b1 = g^a2 * y^b2, so x = (a1 - a2) / (b2 - b1) mod q.
+// Returns `false` if y is not a power of g.
+func PollardRho(g *big.Int, y *big.Int, p *big.Int, q *big.Int) (*big.Int, bool) {
+	if y.Cmp(one) == 0 { return big.NewInt(0), true }
+	three := big.NewInt(3)
+	r := new(big.Int)
+	step := func(z, a, b *big.Int) {
+		switch r.Mod(z, three).Int64() {
+		case 0:
+			z.Mul(z, y)
+			b.Add(b, one)
+		case 1:
+			z.Mul(z, z)
+			a.Lsh(a, 1)
+			b.Lsh(b, 1)
+		case 2:
+			z.Mul(z, g)
+			a.Add(a, one)
+		}
+		z.Mod(z, p)
+		a.Mod(a, q)
+		b.Mod(b, q)
+	}
+
+	// Each attempt starts from a random g^a * y^b. The walk can fail when b1 = b2.
+	for attempt := 0; attempt < 20; attempt++ {
+		a1, _ := rand.Int(rand.Reader, q)
+		b1, _ := rand.Int(rand.Reader, q)
+		z1 := new(big.Int).Exp(g, a1, p)
+		z1.Mul(z1, new(big.Int).Exp(y, b1, p))
+		z1.Mod(z1, p)
+		z2, a2, b2 := new(big.Int).Set(z1), new(big.Int).Set(a1), new(big.Int).Set(b1)
+
+		// Without a collision after a few times sqrt(q) steps, y is probably not in the group
+		limit := int64(math.MaxInt64)
+		if l := new(big.Int).Sqrt(q); l.Lsh(l, 4).IsInt64() { limit = l.Int64() }
+		for i := int64(0); i < limit; i++ {
+			step(z1, a1, b1)
+			step(z2, a2, b2)
+			step(z2, a2, b2)
+			if z1.Cmp(z2) == 0 { break }
+		}
+		if z1.Cmp(z2) != 0 { return nil, false }
+
+		db := new(big.Int).Sub(b2, b1)
+		db.Mod(db, q)
+		dbInverse := new(big.Int).ModInverse(db, q)
+		if dbInverse == nil { continue }
+		x := new(big.Int).Sub(a1, a2)
+		x.Mul(x, dbInverse)
+		x.Mod(x, q)
+		if new(big.Int).Exp(g, x, p).Cmp(y) == 0 { return x, true }
+	}
+	return nil, false
+}
@@ -0,0 +1,59 @@
+package dlog
+
+import (
+	"crypto/rand"
+	"math/big"
+	"../numutil"
+)
+
+// Small subgroup confinement attack on a Diffie-Hellman server that doesn't validate public keys.
+//
+// The server's key x is used in a group of prime order q, but the whole group mod p has order
+// p - 1 = q * j, and j has small factors. For each small prime r dividing j, we send an element
+// h of order r instead of a public key. The server computes K = h^x, which is one of only r
+// values, and uses it to authenticate a message. Trying all the possible values of K until the
+// MAC matches gives x mod r. Once the product of the r is larger than q, the CRT gives x.
+
+// Sends h to the server as our public key, and returns the server's MAC
+type Oracle func(h *big.Int) []byte
+
+// Tells whether the MAC was made with the key derived from the shared secret k
+type MACChecker func(k *big.Int, mac []byte) bool
+
+// Returns x mod r for the small factors r of (p - 1) / q (up to bound), and the product of the
+// r. Stops as soon as the product is larger than q, in which case the residue is x itself.
+func SmallSubgroupAttack(p *big.Int, q *big.Int, bound int64, oracle Oracle, check MACChecker) (*big.Int, *big.Int) {
+	pMinusOne := new(big.Int).Sub(p, one)
+	j := new(big.Int).Div(pMinusOne, q)
+
+	var residues []*big.Int
+	var moduli []*big.Int
+	product := big.NewInt(1)
+	for _, r := range numutil.SmallFactors(j, bound) {
+		// Factors of q don't give any information about x mod q
+		if new(big.Int).Mod(q, r).Sign() == 0 { continue }
+
+		// Element of order r: a random element raised to (p - 1) / r, unless that gives 1
+		h := big.NewInt(1)
+		cofactor := new(big.Int).Div(pMinusOne, r)
+		for h.Cmp(one) == 0 {
+			e, _ := rand.Int(rand.Reader, p)
+			h.Exp(e, cofactor, p)
+		}
+
+		mac := oracle(h)
+		k := big.NewInt(1)
+		for i := int64(0); i < r.Int64(); i++ {
+			if check(k, mac) {
+				residues = append(residues, big.NewInt(i))
+				moduli = append(moduli, r)
+				product.Mul(product, r)
+				break
+			}
+			k.Mul(k, h)
+			k.Mod(k, p)
+		}
+		if product.Cmp(q) > 0 { break }
+	}
+	return numutil.CRT(residues, moduli), product
+}
@@ -0,0 +1,171 @@
+package dsa
+
+import (
+	"crypto/rand"
+	"math/big"
+	"../mdhash"
+	"../rsa"
+)
+
+// DSA (FIPS 186), with SHA-1 as the message hash. The parameters are a prime p, a prime q
+// dividing p - 1, and a generator g of the subgroup of order q. The private key is x in
+// [1, q - 1] and the public key is y = g^x mod p. A signature of H(m) with nonce k is:
+//
+// r = (g^k mod p) mod q
+// s = k^-1 * (H(m) + x * r) mod q
+//
+// Anyone who learns the nonce of a signature can compute x = (s * k - H(m)) / r mod q.
+
+var one = big.NewInt(1)
+
+type Parameters struct {
+	P *big.Int
+	Q *big.Int
+	G *big.Int
+}
+
+type PublicKey struct {
+	Parameters
+	Y *big.Int
+}
+
+type PrivateKey struct {
+	PublicKey
+	X *big.Int
+}
+
+type Signature struct {
+	R *big.Int
+	S *big.Int
+}
+
+func fromHex(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 16)
+	return n
+}
+
+// Standard 1024-bit parameters, with a 160-bit q
+func DefaultParameters() *Parameters {
+	return &Parameters{
+		P: fromHex("800000000000000089e1855218a0e7dac38136ffafa72eda7859f2171e25e65eac698c1702578b07dc2a1076da241c76c62d374d8389ea5aeffd3226a0530cc565f3bf6b50929139ebeac04f48c3c84afb796d61e5a4f9a8fda812ab59494232c7d2b4deb50aa18ee9e132bfa85ac4374d7f9091abc3d015efc871a584471bb1"),
+		Q: fromHex("f4f47f05794b256174bba6e9b396a7707e563c5b"),
+		G: fromHex("5958c9d3898b224b12672c0b98e06c60df923cb8bc999d119458fef538b8fa4046c8db53039db620c094c9fa077ef389b5322a559946a71903f990f1f7e0e025e2d7f7cf494aff1a0470f5b64c36b625a097f1651fe775323556fe00b3608c887892878480e99041be601a62166ca6894bdd41a7054ec89f756ba9fc95302291"),
+	}
+}
+
+// Generates new parameters with a pBits-bit p and a qBits-bit q. This is simpler than the
+// FIPS 186 procedure (which allows the parameters to be checked): we look for p = q * m + 1
+// with random m, then take g = h^((p - 1) / q) for some h.
+func GenerateParameters(pBits int, qBits int) *Parameters {
+	q := rsa.GeneratePrime(qBits)
+	for {
+		m, _ := rand.Int(rand.Reader, new(big.Int).Lsh(one, uint(pBits - qBits)))
+		m.SetBit(m, pBits - qBits - 1, 1)
+		m.SetBit(m, 0, 0)
+		p := new(big.Int).Mul(q, m)
+		p.Add(p, one)
+		if p.BitLen() != pBits || !rsa.IsProbablePrime(p, 20) { continue }
+
+		for h := int64(2); ; h++ {
+			g := new(big.Int).Exp(big.NewInt(h), m, p)
+			if g.Cmp(one) != 0 {
+				return &Parameters{p, q, g}
+			}
+		}
+	}
+}
+
+func GenerateKey(params *Parameters) *PrivateKey {
+	x, _ := rand.Int(rand.Reader, new(big.Int).Sub(params.Q, one))
+	x.Add(x, one)
+	return NewPrivateKey(params, x)
+}
+
+func NewPrivateKey(params *Parameters, x *big.Int) *PrivateKey {
+	y := new(big.Int).Exp(params.G, x, params.P)
+	return &PrivateKey{PublicKey{*params, y}, x}
+}
+
+// SHA-1 of the message, as an integer
+func HashMessage(message []byte) *big.Int {
+	return new(big.Int).SetBytes(mdhash.SHA1(message))
+}
+
+func Sign(key *PrivateKey, message []byte) *Signature {
+	h := HashMessage(message)
+	for {
+		k, _ := rand.Int(rand.Reader, new(big.Int).Sub(key.Q, one))
+		k.Add(k, one)
+		signature, ok := SignWithNonce(key, h, k)
+		if ok { return signature }
+	}
+}
+
+// Signs the hash h with the given nonce. Returns `false` if r or s is 0, in which case the
+// standard says to try again with a new nonce.
+func SignWithNonce(key *PrivateKey, h *big.Int, k *big.Int) (*Signature, bool) {
+	r := new(big.Int).Exp(key.G, k, key.P)
+	r.Mod(r, key.Q)
+	kInv := new(big.Int).ModInverse(k, key.Q)
+	if r.Sign() == 0 || kInv == nil { return nil, false }
+	s := new(big.Int).Mul(key.X, r)
+	s.Add(s, h)
+	s.Mul(s, kInv)
+	s.Mod(s, key.Q)
+	if s.Sign() == 0 { return nil, false }
+	return &Signature{r, s}, true
+}
+
+func inRange(n *big.Int, q *big.Int) bool {
+	return n.Sign() > 0 && n.Cmp(q) < 0
+}
+
+func Verify(key *PublicKey, message []byte, signature *Signature) bool {
+	if !inRange(signature.R, key.Q) || !inRange(signature.S, key.Q) { return false }
+	return VerifySloppy(key, message, signature)
+}
+
+// Verifier that doesn't check that 0 < r < q and 0 < s < q. With a generator that is 0 mod p,
+// v is always 0, so r = 0 is valid for any message.
+func VerifySloppy(key *PublicKey, message []byte, signature *Signature) bool {
+	w := new(big.Int).ModInverse(signature.S, key.Q)
+	if w == nil { w = new(big.Int) }
+	u1 := new(big.Int).Mul(HashMessage(message), w)
+	u1.Mod(u1, key.Q)
+	u2 := new(big.Int).Mul(signature.R, w)
+	u2.Mod(u2, key.Q)
+	v := new(big.Int).Exp(key.G, u1, key.P)
+	v.Mul(v, new(big.Int).Exp(key.Y, u2, key.P))
+	v.Mod(v, key.P)
+	v.Mod(v, key.Q)
+	return v.Cmp(signature.R) == 0
+}
+
+// Recovers the private key from a signature of h and its nonce: x = (s * k - h) / r mod q.
+// Returns `false` if r is not invertible.
+func RecoverPrivateKey(params *Parameters, h *big.Int, signature *Signature, k *big.Int) (*big.Int, bool) {
+	rInv := new(big.Int).ModInverse(signature.R, params.Q)
+	if rInv == nil { return nil, false }
+	x := new(big.Int).Mul(signature.S, k)
+	x.Sub(x, h)
+	x.Mul(x, rInv)
+	return x.Mod(x, params.Q), true
+}
+
+// Recovers the nonce shared by two signatures of h1 and h2:
+// s1 - s2 = k^-1 * (h1 - h2), so k = (h1 - h2) / (s1 - s2) mod q.
+// Returns `false` if s1 = s2.
+func RecoverNonce(params *Parameters, h1 *big.Int, signature1 *Signature, h2 *big.Int, signature2 *Signature) (*big.Int, bool) {
+	ds := new(big.Int).Sub(signature1.S, signature2.S)
+	ds.Mod(ds, params.Q)
+	dsInv := new(big.Int).ModInverse(ds, params.Q)
+	if dsInv == nil { return nil, false }
+	k := new(big.Int).Sub(h1, h2)
+	k.Mul(k, dsInv)
+	return k.Mod(k, params.Q), true
+}
+
+// Tells whether x is the private key matching the public key
+func IsPrivateKey(key *PublicKey, x *big.Int) bool {
+	return new(big.Int).Exp(key.G, x, key.P).Cmp(key.Y) == 0
+}
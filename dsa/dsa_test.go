@@ -0,0 +1,70 @@
+package dsa
+
+import (
+	stddsa "crypto/dsa"
+	"math/big"
+	"testing"
+	"../mdhash"
+)
+
+func TestDefaultParameters(t *testing.T) {
+	params := DefaultParameters()
+	if !params.P.ProbablyPrime(20) || !params.Q.ProbablyPrime(20) {
+		t.Errorf("p and q should be prime")
+	}
+	if new(big.Int).Mod(new(big.Int).Sub(params.P, one), params.Q).Sign() != 0 {
+		t.Errorf("q should divide p - 1")
+	}
+	if new(big.Int).Exp(params.G, params.Q, params.P).Cmp(one) != 0 {
+		t.Errorf("g should have order q")
+	}
+}
+
+func TestGenerateParameters(t *testing.T) {
+	params := GenerateParameters(512, 160)
+	if params.P.BitLen() != 512 || params.Q.BitLen() != 160 || !params.P.ProbablyPrime(20) {
+		t.Errorf("invalid parameters")
+	}
+	if new(big.Int).Exp(params.G, params.Q, params.P).Cmp(one) != 0 {
+		t.Errorf("g should have order q")
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	key := GenerateKey(DefaultParameters())
+	message := []byte("hi mom")
+	signature := Sign(key, message)
+	if !Verify(&key.PublicKey, message, signature) {
+		t.Errorf("valid signature rejected")
+	}
+	if Verify(&key.PublicKey, []byte("hi dad"), signature) {
+		t.Errorf("signature accepted for the wrong message")
+	}
+
+	// Cross-check with the standard library
+	stdKey := stddsa.PublicKey{
+		Parameters: stddsa.Parameters{P: key.P, Q: key.Q, G: key.G},
+		Y: key.Y,
+	}
+	if !stddsa.Verify(&stdKey, mdhash.SHA1(message), signature.R, signature.S) {
+		t.Errorf("signature rejected by crypto/dsa")
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	key := GenerateKey(DefaultParameters())
+	h1 := HashMessage([]byte("hi mom"))
+	h2 := HashMessage([]byte("hi dad"))
+	k := big.NewInt(12345)
+	signature1, _ := SignWithNonce(key, h1, k)
+	signature2, _ := SignWithNonce(key, h2, k)
+
+	x, ok := RecoverPrivateKey(&key.Parameters, h1, signature1, k)
+	if !ok || x.Cmp(key.X) != 0 || !IsPrivateKey(&key.PublicKey, x) {
+		t.Errorf("could not recover the private key from the nonce")
+	}
+	recovered, ok := RecoverNonce(&key.Parameters, h1, signature1, h2, signature2)
+	if !ok || recovered.Cmp(k) != 0 {
+		t.Errorf("could not recover the shared nonce, got %v", recovered)
+	}
+}
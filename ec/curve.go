@@ -0,0 +1,172 @@
+package ec
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// Elliptic curves in short Weierstrass form, y^2 = x^3 + a * x + b over GF(p), with affine
+// coordinates. None of the operations check that the points are actually on the curve: the
+// formulas for addition don't depend on b, so with a point from another curve (same a, different
+// b), they compute on that other curve instead.
+
+var zero = big.NewInt(0)
+var one = big.NewInt(1)
+var two = big.NewInt(2)
+var three = big.NewInt(3)
+
+type Curve struct {
+	P *big.Int
+	A *big.Int
+	B *big.Int
+}
+
+// The point at infinity (the identity) has nil coordinates
+type Point struct {
+	X *big.Int
+	Y *big.Int
+}
+
+func Infinity() Point {
+	return Point{}
+}
+
+func NewPoint(x int64, y int64) Point {
+	return Point{big.NewInt(x), big.NewInt(y)}
+}
+
+func (this Point) IsInfinity() bool {
+	return this.X == nil
+}
+
+func (this Point) Equal(other Point) bool {
+	if this.IsInfinity() || other.IsInfinity() { return this.IsInfinity() && other.IsInfinity() }
+	return this.X.Cmp(other.X) == 0 && this.Y.Cmp(other.Y) == 0
+}
+
+func (this Point) String() string {
+	if this.IsInfinity() { return "(infinity)" }
+	return "(" + this.X.String() + ", " + this.Y.String() + ")"
+}
+
+// Encoding of the point, with both coordinates as size-byte big-endian integers
+func (this Point) Bytes(size int) []byte {
+	output := make([]byte, 2 * size)
+	if this.IsInfinity() { return output }
+	this.X.FillBytes(output[0:size])
+	this.Y.FillBytes(output[size:])
+	return output
+}
+
+// Size of the coordinates in bytes
+func (this *Curve) ByteSize() int {
+	return (this.P.BitLen() + 7) / 8
+}
+
+// x^3 + a * x + b mod p
+func (this *Curve) rhs(x *big.Int) *big.Int {
+	output := new(big.Int).Mul(x, x)
+	output.Add(output, this.A)
+	output.Mul(output, x)
+	output.Add(output, this.B)
+	return output.Mod(output, this.P)
+}
+
+func (this *Curve) IsOnCurve(pt Point) bool {
+	if pt.IsInfinity() { return true }
+	y2 := new(big.Int).Mul(pt.Y, pt.Y)
+	return y2.Mod(y2, this.P).Cmp(this.rhs(pt.X)) == 0
+}
+
+// Returns a point with the given x coordinate, or `false` if there is none
+func (this *Curve) Lift(x *big.Int) (Point, bool) {
+	x = new(big.Int).Mod(x, this.P)
+	y := new(big.Int).ModSqrt(this.rhs(x), this.P)
+	if y == nil { return Infinity(), false }
+	return Point{x, y}, true
+}
+
+func (this *Curve) RandomPoint() Point {
+	for {
+		x, _ := rand.Int(rand.Reader, this.P)
+		pt, ok := this.Lift(x)
+		if ok { return pt }
+	}
+}
+
+func (this *Curve) Neg(pt Point) Point {
+	if pt.IsInfinity() { return pt }
+	y := new(big.Int).Neg(pt.Y)
+	return Point{new(big.Int).Set(pt.X), y.Mod(y, this.P)}
+}
+
+func (this *Curve) Add(p1 Point, p2 Point) Point {
+	if p1.IsInfinity() { return p2 }
+	if p2.IsInfinity() { return p1 }
+	if p1.Equal(this.Neg(p2)) { return Infinity() }
+
+	// Slope of the line through both points, or of the tangent if they are equal
+	var m *big.Int
+	if p1.Equal(p2) {
+		m = new(big.Int).Mul(p1.X, p1.X)
+		m.Mul(m, three)
+		m.Add(m, this.A)
+		m.Mul(m, new(big.Int).ModInverse(new(big.Int).Mul(two, p1.Y), this.P))
+	} else {
+		m = new(big.Int).Sub(p2.Y, p1.Y)
+		dx := new(big.Int).Sub(p2.X, p1.X)
+		m.Mul(m, new(big.Int).ModInverse(dx.Mod(dx, this.P), this.P))
+	}
+	m.Mod(m, this.P)
+
+	x := new(big.Int).Mul(m, m)
+	x.Sub(x, p1.X)
+	x.Sub(x, p2.X)
+	x.Mod(x, this.P)
+	y := new(big.Int).Sub(p1.X, x)
+	y.Mul(y, m)
+	y.Sub(y, p1.Y)
+	y.Mod(y, this.P)
+	return Point{x, y}
+}
+
+// k * pt, with double-and-add. Negative k are allowed.
+func (this *Curve) ScalarMult(pt Point, k *big.Int) Point {
+	if k.Sign() < 0 { return this.ScalarMult(this.Neg(pt), new(big.Int).Neg(k)) }
+	output := Infinity()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		output = this.Add(output, output)
+		if k.Bit(i) == 1 {
+			output = this.Add(output, pt)
+		}
+	}
+	return output
+}
+
+// Domain parameters: a curve, a base point and its (prime) order
+type Parameters struct {
+	Curve *Curve
+	G Point
+	N *big.Int
+}
+
+func fromDec(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+// A 128-bit curve, small enough to make the attacks practical:
+// y^2 = x^3 - 95051 * x + 11279326, with a base point of prime order N. The curve has 8 * N
+// points.
+func DefaultParameters() *Parameters {
+	curve := &Curve{
+		P: fromDec("233970423115425145524320034830162017933"),
+		A: big.NewInt(-95051),
+		B: big.NewInt(11279326),
+	}
+	return &Parameters{
+		Curve: curve,
+		G: Point{big.NewInt(182), fromDec("85518893674295321206118380980485522083")},
+		N: fromDec("29246302889428143187362802287225875743"),
+	}
+}
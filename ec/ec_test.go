@@ -0,0 +1,135 @@
+package ec
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+	"../lattice"
+)
+
+func TestBasePoint(t *testing.T) {
+	params := DefaultParameters()
+	if !params.Curve.IsOnCurve(params.G) {
+		t.Errorf("G is not on the curve")
+	}
+	if !params.Curve.ScalarMult(params.G, params.N).IsInfinity() {
+		t.Errorf("G doesn't have order N")
+	}
+	// 8 * N points in total
+	pt := params.Curve.RandomPoint()
+	if !params.Curve.ScalarMult(pt, new(big.Int).Mul(params.N, big.NewInt(8))).IsInfinity() {
+		t.Errorf("the curve doesn't have 8 * N points")
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	params := DefaultParameters()
+	c := params.Curve
+	g2 := c.Add(params.G, params.G)
+	g3 := c.Add(g2, params.G)
+	if !c.IsOnCurve(g2) || !c.IsOnCurve(g3) {
+		t.Errorf("results are not on the curve")
+	}
+	if !c.ScalarMult(params.G, big.NewInt(3)).Equal(g3) {
+		t.Errorf("3 * G != G + G + G")
+	}
+	if !c.Add(g3, c.Neg(g3)).IsInfinity() || !c.Add(g3, Infinity()).Equal(g3) {
+		t.Errorf("identity or negation is broken")
+	}
+	a := RandomScalar(params.N)
+	b := RandomScalar(params.N)
+	sum := c.Add(c.ScalarMult(params.G, a), c.ScalarMult(params.G, b))
+	if !sum.Equal(c.ScalarMult(params.G, new(big.Int).Add(a, b))) {
+		t.Errorf("a * G + b * G != (a + b) * G")
+	}
+}
+
+func TestMontgomery(t *testing.T) {
+	params := DefaultParameters()
+	mparams := DefaultMontgomeryParameters()
+	m := mparams.Curve
+	w := m.Weierstrass()
+	if w.A.Cmp(new(big.Int).Mod(params.Curve.A, params.Curve.P)) != 0 || w.B.Cmp(params.Curve.B) != 0 {
+		t.Errorf("wrong Weierstrass form: %v %v", w.A, w.B)
+	}
+	if m.ToWeierstrassX(mparams.U).Cmp(params.G.X) != 0 || m.FromWeierstrassX(params.G.X).Cmp(mparams.U) != 0 {
+		t.Errorf("the base points don't match")
+	}
+	if m.Ladder(mparams.U, mparams.N).Sign() != 0 {
+		t.Errorf("the base point doesn't have order N")
+	}
+	for i := 0; i < 5; i++ {
+		k := RandomScalar(params.N)
+		expected := params.Curve.ScalarMult(params.G, k)
+		if m.ToWeierstrassX(m.Ladder(mparams.U, k)).Cmp(expected.X) != 0 {
+			t.Errorf("ladder and double-and-add disagree for k = %v", k)
+		}
+	}
+	// Points on the twist don't have a matching point on the Weierstrass curve
+	for u := int64(1); u < 20; u++ {
+		_, ok := w.Lift(m.ToWeierstrassX(big.NewInt(u)))
+		if m.IsOnCurve(big.NewInt(u)) != ok {
+			t.Errorf("IsOnCurve is wrong for u = %d", u)
+		}
+	}
+}
+
+func TestECDH(t *testing.T) {
+	params := DefaultParameters()
+	alice := GenerateKey(params)
+	bob := GenerateKey(params)
+	if !ValidatePublicKey(params, alice.Y) || !ValidatePublicKey(params, bob.Y) {
+		t.Errorf("valid public keys rejected")
+	}
+	if !SharedSecret(alice, bob.Y).Equal(SharedSecret(bob, alice.Y)) {
+		t.Errorf("shared secrets differ")
+	}
+	if ValidatePublicKey(params, NewPoint(1, 2)) {
+		t.Errorf("point not on the curve accepted")
+	}
+	// A point of order 2 on the curve, not in the subgroup
+	order2 := params.Curve.ScalarMult(params.Curve.RandomPoint(), new(big.Int).Mul(params.N, big.NewInt(4)))
+	if !order2.IsInfinity() && ValidatePublicKey(params, order2) {
+		t.Errorf("point outside of the subgroup accepted")
+	}
+
+	mparams := DefaultMontgomeryParameters()
+	malice := GenerateMontgomeryKey(mparams)
+	mbob := GenerateMontgomeryKey(mparams)
+	if MontgomerySharedSecret(malice, mbob.Y).Cmp(MontgomerySharedSecret(mbob, malice.Y)) != 0 {
+		t.Errorf("Montgomery shared secrets differ")
+	}
+}
+
+func TestECDSA(t *testing.T) {
+	key := GenerateKey(DefaultParameters())
+	message := []byte("hi mom")
+	signature := Sign(key, message)
+	if !Verify(&key.PublicKey, message, signature) {
+		t.Errorf("valid signature rejected")
+	}
+	if Verify(&key.PublicKey, []byte("hi dad"), signature) {
+		t.Errorf("signature accepted for the wrong message")
+	}
+}
+
+// The hidden number problem attack works the same with ECDSA as with DSA
+func TestECDSABiasedNonce(t *testing.T) {
+	params := DefaultParameters()
+	key := GenerateKey(params)
+	zeroBits := 8
+	r := rand.New(rand.NewSource(1))
+	var samples []lattice.Sample
+	for i := 0; i < 22; i++ {
+		h := HashMessage(params, []byte{byte(i)})
+		k := new(big.Int).Rand(r, new(big.Int).Rsh(params.N, uint(zeroBits)))
+		k.Lsh(k, uint(zeroBits))
+		signature, ok := SignWithNonce(key, h, k)
+		if !ok { continue }
+		samples = append(samples, lattice.Sample{H: h, R: signature.R, S: signature.S})
+	}
+	x, ok := lattice.RecoverBiasedNonceKey(params.N, samples, zeroBits)
+	if !ok || x.Cmp(key.X) != 0 {
+		t.Errorf("could not recover the private key, got %v", x)
+	}
+}
@@ -0,0 +1,69 @@
+package ec
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+)
+
+type PublicKey struct {
+	Parameters
+	Y Point
+}
+
+type PrivateKey struct {
+	PublicKey
+	X *big.Int
+}
+
+// Returns a random integer in [1, n - 1]
+func RandomScalar(n *big.Int) *big.Int {
+	x, _ := rand.Int(rand.Reader, new(big.Int).Sub(n, one))
+	return x.Add(x, one)
+}
+
+func GenerateKey(params *Parameters) *PrivateKey {
+	return NewPrivateKey(params, RandomScalar(params.N))
+}
+
+func NewPrivateKey(params *Parameters, x *big.Int) *PrivateKey {
+	y := params.Curve.ScalarMult(params.G, x)
+	return &PrivateKey{PublicKey{*params, y}, x}
+}
+
+// ECDH: x * Y for the peer's public key Y. The point isn't checked, see ValidatePublicKey.
+func SharedSecret(key *PrivateKey, peer Point) Point {
+	return key.Curve.ScalarMult(peer, key.X)
+}
+
+// Checks that the point is on the curve and in the subgroup generated by G. Without this,
+// a peer can send a point of small order on another curve, and learn the private key modulo
+// that order from the result.
+func ValidatePublicKey(params *Parameters, pt Point) bool {
+	if pt.IsInfinity() || !params.Curve.IsOnCurve(pt) { return false }
+	if pt.X.Sign() < 0 || pt.X.Cmp(params.Curve.P) >= 0 || pt.Y.Sign() < 0 || pt.Y.Cmp(params.Curve.P) >= 0 { return false }
+	return params.Curve.ScalarMult(pt, params.N).IsInfinity()
+}
+
+// Derives a symmetric key from the encoding of the shared secret
+func DeriveKey(secret []byte) []byte {
+	h := sha256.Sum256(secret)
+	return h[:]
+}
+
+// Montgomery ECDH: the public key is the u coordinate u(x * U)
+type MontgomeryPrivateKey struct {
+	MontgomeryParameters
+	Y *big.Int
+	X *big.Int
+}
+
+func GenerateMontgomeryKey(params *MontgomeryParameters) *MontgomeryPrivateKey {
+	x := RandomScalar(params.N)
+	return &MontgomeryPrivateKey{*params, params.Curve.Ladder(params.U, x), x}
+}
+
+// Computes u(x * u) with the ladder. The peer's u isn't checked, so it can be on the twist.
+func MontgomerySharedSecret(key *MontgomeryPrivateKey, u *big.Int) *big.Int {
+	return key.Curve.Ladder(u, key.X)
+}
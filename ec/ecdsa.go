@@ -0,0 +1,61 @@
+package ec
+
+import (
+	"crypto/sha256"
+	"math/big"
+)
+
+// ECDSA, with SHA-256. Same as DSA, with r = x(k * G) mod n:
+//
+// s = k^-1 * (H(m) + x * r) mod n
+
+type Signature struct {
+	R *big.Int
+	S *big.Int
+}
+
+// SHA-256 of the message, truncated to the size of n
+func HashMessage(params *Parameters, message []byte) *big.Int {
+	h := sha256.Sum256(message)
+	output := new(big.Int).SetBytes(h[:])
+	if excess := 256 - params.N.BitLen(); excess > 0 {
+		output.Rsh(output, uint(excess))
+	}
+	return output
+}
+
+func Sign(key *PrivateKey, message []byte) *Signature {
+	h := HashMessage(&key.Parameters, message)
+	for {
+		signature, ok := SignWithNonce(key, h, RandomScalar(key.N))
+		if ok { return signature }
+	}
+}
+
+// Signs the hash h with the given nonce. Returns `false` if r or s is 0.
+func SignWithNonce(key *PrivateKey, h *big.Int, k *big.Int) (*Signature, bool) {
+	pt := key.Curve.ScalarMult(key.G, k)
+	if pt.IsInfinity() { return nil, false }
+	r := new(big.Int).Mod(pt.X, key.N)
+	kInv := new(big.Int).ModInverse(k, key.N)
+	if r.Sign() == 0 || kInv == nil { return nil, false }
+	s := new(big.Int).Mul(key.X, r)
+	s.Add(s, h)
+	s.Mul(s, kInv)
+	s.Mod(s, key.N)
+	if s.Sign() == 0 { return nil, false }
+	return &Signature{r, s}, true
+}
+
+func Verify(key *PublicKey, message []byte, signature *Signature) bool {
+	n := key.N
+	if signature.R.Sign() <= 0 || signature.R.Cmp(n) >= 0 || signature.S.Sign() <= 0 || signature.S.Cmp(n) >= 0 { return false }
+	w := new(big.Int).ModInverse(signature.S, n)
+	u1 := new(big.Int).Mul(HashMessage(&key.Parameters, message), w)
+	u1.Mod(u1, n)
+	u2 := new(big.Int).Mul(signature.R, w)
+	u2.Mod(u2, n)
+	pt := key.Curve.Add(key.Curve.ScalarMult(key.G, u1), key.Curve.ScalarMult(key.Y, u2))
+	if pt.IsInfinity() { return false }
+	return new(big.Int).Mod(pt.X, n).Cmp(signature.R) == 0
+}
@@ -0,0 +1,144 @@
+package ec
+
+import (
+	"math/big"
+)
+
+// Montgomery curves, B * v^2 = u^3 + A * u^2 + u over GF(p). Scalar multiplication uses the
+// Montgomery ladder, which only needs the u coordinate (like X25519).
+//
+// For a given u, u^3 + A * u^2 + u is either a square, in which case u is the coordinate of a
+// point on the curve, or not, in which case it is a point on the quadratic twist of the curve
+// (with d * B * v^2 = ... for a non-square d). The ladder formulas don't depend on B, so they
+// work on the twist too. An implementation that only uses the ladder and doesn't check that u
+// is on the curve is then exposed to the small subgroups of the twist.
+
+type MontgomeryCurve struct {
+	P *big.Int
+	A *big.Int
+	B *big.Int
+}
+
+// u^3 + A * u^2 + u mod p
+func (this *MontgomeryCurve) rhs(u *big.Int) *big.Int {
+	output := new(big.Int).Add(u, this.A)
+	output.Mul(output, u)
+	output.Add(output, one)
+	output.Mul(output, u)
+	return output.Mod(output, this.P)
+}
+
+// Tells whether u is the coordinate of a point on the curve, rather than on its twist
+func (this *MontgomeryCurve) IsOnCurve(u *big.Int) bool {
+	rhs := new(big.Int).Mul(this.rhs(u), new(big.Int).ModInverse(this.B, this.P))
+	return big.Jacobi(rhs.Mod(rhs, this.P), this.P) >= 0
+}
+
+// Returns the u coordinate of k * (u, v), or 0 for the point at infinity. The number of
+// iterations only depends on the size of p.
+func (this *MontgomeryCurve) Ladder(u *big.Int, k *big.Int) *big.Int {
+	p := this.P
+	u2, w2 := big.NewInt(1), big.NewInt(0)
+	u3, w3 := new(big.Int).Mod(u, p), big.NewInt(1)
+	t1 := new(big.Int)
+	t2 := new(big.Int)
+	for i := p.BitLen() - 1; i >= 0; i-- {
+		b := k.Bit(i)
+		if b == 1 {
+			u2, u3 = u3, u2
+			w2, w3 = w3, w2
+		}
+
+		// (u3, w3) = (u2 * u3 - w2 * w3)^2, u * (u2 * w3 - w2 * u3)^2
+		t1.Mul(u2, u3)
+		t2.Mul(w2, w3)
+		newU3 := new(big.Int).Sub(t1, t2)
+		newU3.Mul(newU3, newU3)
+		newU3.Mod(newU3, p)
+		t1.Mul(u2, w3)
+		t2.Mul(w2, u3)
+		newW3 := new(big.Int).Sub(t1, t2)
+		newW3.Mul(newW3, newW3)
+		newW3.Mul(newW3, u)
+		newW3.Mod(newW3, p)
+
+		// (u2, w2) = (u2^2 - w2^2)^2, 4 * u2 * w2 * (u2^2 + A * u2 * w2 + w2^2)
+		t1.Mul(u2, u2)
+		t2.Mul(w2, w2)
+		newU2 := new(big.Int).Sub(t1, t2)
+		newU2.Mul(newU2, newU2)
+		newU2.Mod(newU2, p)
+		newW2 := new(big.Int).Mul(this.A, u2)
+		newW2.Mul(newW2, w2)
+		newW2.Add(newW2, t1)
+		newW2.Add(newW2, t2)
+		newW2.Mul(newW2, u2)
+		newW2.Mul(newW2, w2)
+		newW2.Lsh(newW2, 2)
+		newW2.Mod(newW2, p)
+
+		u2, w2, u3, w3 = newU2, newW2, newU3, newW3
+		if b == 1 {
+			u2, u3 = u3, u2
+			w2, w3 = w3, w2
+		}
+	}
+	inverse := new(big.Int).ModInverse(w2, p)
+	if inverse == nil { return big.NewInt(0) }
+	return inverse.Mul(inverse, u2).Mod(inverse, p)
+}
+
+// The isomorphic curve in Weierstrass form. With x = u / B + A / (3 * B) and y = v / B:
+// y^2 = x^3 + a * x + b, with a = (3 - A^2) / (3 * B^2) and b = (2 * A^3 - 9 * A) / (27 * B^3)
+func (this *MontgomeryCurve) Weierstrass() *Curve {
+	p := this.P
+	inv := func(n *big.Int) *big.Int {
+		return new(big.Int).ModInverse(new(big.Int).Mod(n, p), p)
+	}
+	b2 := new(big.Int).Mul(this.B, this.B)
+	b3 := new(big.Int).Mul(b2, this.B)
+	a2 := new(big.Int).Mul(this.A, this.A)
+
+	a := new(big.Int).Sub(three, a2)
+	a.Mul(a, inv(new(big.Int).Mul(three, b2)))
+	a.Mod(a, p)
+
+	b := new(big.Int).Mul(a2, this.A)
+	b.Mul(b, two)
+	b.Sub(b, new(big.Int).Mul(big.NewInt(9), this.A))
+	b.Mul(b, inv(new(big.Int).Mul(big.NewInt(27), b3)))
+	b.Mod(b, p)
+	return &Curve{new(big.Int).Set(p), a, b}
+}
+
+// Maps a u coordinate to the x coordinate on the Weierstrass curve, and back
+func (this *MontgomeryCurve) ToWeierstrassX(u *big.Int) *big.Int {
+	x := new(big.Int).Mul(this.B, three)
+	x.ModInverse(x.Mod(x, this.P), this.P)
+	x.Mul(x, new(big.Int).Add(new(big.Int).Mul(u, three), this.A))
+	return x.Mod(x, this.P)
+}
+
+func (this *MontgomeryCurve) FromWeierstrassX(x *big.Int) *big.Int {
+	u := new(big.Int).Mul(x, this.B)
+	a3 := new(big.Int).ModInverse(three, this.P)
+	u.Sub(u, a3.Mul(a3, this.A))
+	return u.Mod(u, this.P)
+}
+
+type MontgomeryParameters struct {
+	Curve *MontgomeryCurve
+	U *big.Int // base point
+	N *big.Int // order of the base point
+}
+
+// The Montgomery form of the curve in DefaultParameters: v^2 = u^3 + 534 * u^2 + u, with
+// u = x - 178. The base point u = 4 maps to the base point of DefaultParameters.
+func DefaultMontgomeryParameters() *MontgomeryParameters {
+	params := DefaultParameters()
+	return &MontgomeryParameters{
+		Curve: &MontgomeryCurve{params.Curve.P, big.NewInt(534), big.NewInt(1)},
+		U: big.NewInt(4),
+		N: params.N,
+	}
+}
@@ -0,0 +1,95 @@
+package gf128
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Arithmetic in GF(2^128), using the GCM representation: the field is defined by
+// the polynomial x^128 + x^7 + x^2 + x + 1 and the bits are "reflected", i.e. the
+// most significant bit of the first byte is the coefficient of x^0.
+// See NIST SP 800-38D, section 6.3.
+
+type Element struct {
+	hi uint64 // coefficients of x^0 (most significant bit) to x^63
+	lo uint64 // coefficients of x^64 to x^127
+}
+
+func NewElement(b []byte) Element {
+	return Element{binary.BigEndian.Uint64(b[0:8]), binary.BigEndian.Uint64(b[8:16])}
+}
+
+func Zero() Element {
+	return Element{0, 0}
+}
+
+func One() Element {
+	return Element{1 << 63, 0}
+}
+
+func (this Element) Bytes() []byte {
+	output := make([]byte, 16)
+	binary.BigEndian.PutUint64(output[0:8], this.hi)
+	binary.BigEndian.PutUint64(output[8:16], this.lo)
+	return output
+}
+
+func (this Element) String() string {
+	return fmt.Sprintf("%x", this.Bytes())
+}
+
+func (this Element) IsZero() bool {
+	return this.hi == 0 && this.lo == 0
+}
+
+// Addition and subtraction are the same operation: XOR
+func (this Element) Add(other Element) Element {
+	return Element{this.hi ^ other.hi, this.lo ^ other.lo}
+}
+
+// Multiplication, using algorithm 1 of SP 800-38D
+func (this Element) Mul(other Element) Element {
+	var z Element
+	v := other
+	for i := 0; i < 128; i++ {
+		var bit uint64
+		if i < 64 {
+			bit = (this.hi >> uint(63 - i)) & 1
+		} else {
+			bit = (this.lo >> uint(127 - i)) & 1
+		}
+		if bit == 1 {
+			z = z.Add(v)
+		}
+		// Multiply v by x, which is a right shift in this representation,
+		// and reduce it if the coefficient of x^128 is set.
+		carry := v.lo & 1
+		v.lo = v.lo >> 1 | v.hi << 63
+		v.hi >>= 1
+		if carry == 1 {
+			v.hi ^= 0xe1 << 56
+		}
+	}
+	return z
+}
+
+// Raises the element to the power 2^n, by squaring it n times
+func (this Element) PowTwo(n int) Element {
+	output := this
+	for i := 0; i < n; i++ {
+		output = output.Mul(output)
+	}
+	return output
+}
+
+// The multiplicative group has order 2^128 - 1, so a^-1 = a^(2^128 - 2)
+// = a^2 * a^4 * ... * a^(2^127)
+func (this Element) Inverse() Element {
+	output := One()
+	square := this
+	for i := 1; i < 128; i++ {
+		square = square.Mul(square)
+		output = output.Mul(square)
+	}
+	return output
+}
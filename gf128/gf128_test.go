@@ -0,0 +1,64 @@
+package gf128
+
+import (
+	"testing"
+)
+
+func TestElement(t *testing.T) {
+	a := NewElement([]byte("YELLOW SUBMARINE"))
+	b := NewElement([]byte("0123456789abcdef"))
+	if a.Mul(b) != b.Mul(a) {
+		t.Errorf("multiplication is not commutative")
+	}
+	if a.Mul(a.Inverse()) != One() {
+		t.Errorf("%s * %s is not one", a, a.Inverse())
+	}
+	if a.Mul(One()) != a || a.Mul(Zero()) != Zero() {
+		t.Errorf("unexpected multiplication by one or zero")
+	}
+	// x * x^127 = x^128 = x^7 + x^2 + x + 1
+	x := Element{1 << 62, 0}
+	x127 := Element{0, 1}
+	if x.Mul(x127) != (Element{0xe1 << 56, 0}) {
+		t.Errorf("unexpected reduction: %s", x.Mul(x127))
+	}
+}
+
+func TestRoots(t *testing.T) {
+	roots := []Element{
+		NewElement([]byte("YELLOW SUBMARINE")),
+		NewElement([]byte("0123456789abcdef")),
+		NewElement([]byte("abcdefghijklmnop")),
+	}
+	// (x + r1) * (x + r2) * (x + r3) * (x^2 + x + c), where the last factor has no roots
+	// in the field if Tr(c) = 1
+	p := Polynomial{One()}
+	for _, r := range roots {
+		p = p.Mul(Polynomial{r, One()})
+	}
+	for i := 0; i < 128; i++ {
+		c := Element{uint64(i) << 32, 0}
+		trace := Zero()
+		for j := 0; j < 128; j++ {
+			trace = trace.Add(c.PowTwo(j))
+		}
+		if trace == One() {
+			p = p.Mul(Polynomial{c, One(), One()})
+			break
+		}
+	}
+
+	found := p.Roots()
+	if len(found) != len(roots) {
+		t.Fatalf("expected %d roots, got %d", len(roots), len(found))
+	}
+	for _, r := range roots {
+		ok := false
+		for _, f := range found {
+			if f == r { ok = true }
+		}
+		if !ok {
+			t.Errorf("root %s not found", r)
+		}
+	}
+}
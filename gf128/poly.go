@@ -0,0 +1,159 @@
+package gf128
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Polynomials with coefficients in GF(2^128). Coefficient i is the coefficient of x^i.
+type Polynomial []Element
+
+// Returns the polynomial without the leading zero coefficients
+func (this Polynomial) trim() Polynomial {
+	n := len(this)
+	for n > 0 && this[n - 1].IsZero() {
+		n--
+	}
+	return this[0:n]
+}
+
+// Returns -1 for the zero polynomial
+func (this Polynomial) Degree() int {
+	return len(this.trim()) - 1
+}
+
+func (this Polynomial) String() string {
+	var terms []string
+	for i := len(this) - 1; i >= 0; i-- {
+		if this[i].IsZero() { continue }
+		terms = append(terms, this[i].String() + "*x^" + strconv.Itoa(i))
+	}
+	if len(terms) == 0 { return "0" }
+	return strings.Join(terms, " + ")
+}
+
+func (this Polynomial) Add(other Polynomial) Polynomial {
+	n := len(this)
+	if len(other) > n { n = len(other) }
+	output := make(Polynomial, n)
+	for i := 0; i < n; i++ {
+		if i < len(this) { output[i] = output[i].Add(this[i]) }
+		if i < len(other) { output[i] = output[i].Add(other[i]) }
+	}
+	return output.trim()
+}
+
+func (this Polynomial) Mul(other Polynomial) Polynomial {
+	a := this.trim()
+	b := other.trim()
+	if len(a) == 0 || len(b) == 0 { return Polynomial{} }
+	output := make(Polynomial, len(a) + len(b) - 1)
+	for i := 0; i < len(a); i++ {
+		for j := 0; j < len(b); j++ {
+			output[i + j] = output[i + j].Add(a[i].Mul(b[j]))
+		}
+	}
+	return output.trim()
+}
+
+// Polynomial long division. Returns the quotient and remainder.
+func (this Polynomial) DivMod(other Polynomial) (Polynomial, Polynomial) {
+	divisor := other.trim()
+	if len(divisor) == 0 { panic("gf128: division by zero polynomial") }
+	remainder := append(Polynomial{}, this.trim()...)
+	if len(remainder) < len(divisor) { return Polynomial{}, remainder }
+
+	quotient := make(Polynomial, len(remainder) - len(divisor) + 1)
+	leadInverse := divisor[len(divisor) - 1].Inverse()
+	for len(remainder) >= len(divisor) {
+		shift := len(remainder) - len(divisor)
+		coef := remainder[len(remainder) - 1].Mul(leadInverse)
+		quotient[shift] = coef
+		for i := 0; i < len(divisor); i++ {
+			remainder[shift + i] = remainder[shift + i].Add(coef.Mul(divisor[i]))
+		}
+		remainder = remainder.trim()
+	}
+	return quotient.trim(), remainder
+}
+
+func (this Polynomial) Mod(other Polynomial) Polynomial {
+	_, output := this.DivMod(other)
+	return output
+}
+
+// Returns the polynomial divided by its leading coefficient
+func (this Polynomial) Monic() Polynomial {
+	p := this.trim()
+	if len(p) == 0 { return p }
+	inverse := p[len(p) - 1].Inverse()
+	output := make(Polynomial, len(p))
+	for i := 0; i < len(p); i++ {
+		output[i] = p[i].Mul(inverse)
+	}
+	return output
+}
+
+// Evaluates the polynomial at x, using Horner's method
+func (this Polynomial) Eval(x Element) Element {
+	output := Zero()
+	for i := len(this) - 1; i >= 0; i-- {
+		output = output.Mul(x).Add(this[i])
+	}
+	return output
+}
+
+// Returns the monic greatest common divisor of a and b
+func Gcd(a Polynomial, b Polynomial) Polynomial {
+	a = a.trim()
+	b = b.trim()
+	for len(b) > 0 {
+		a, b = b, a.Mod(b)
+	}
+	return a.Monic()
+}
+
+// Returns the distinct roots of the polynomial in GF(2^128).
+//
+// Every element of the field is a root of x^(2^128) - x, so gcd(f, x^(2^128) - x) is the
+// product of the linear factors of f. This product is then split using Cantor–Zassenhaus:
+// for a random a, the trace Tr(a * x) = sum(i = 0..127, (a * x)^(2^i)) is either 0 or 1 for
+// each root, so gcd with it separates the roots into two groups.
+func (this Polynomial) Roots() []Element {
+	f := this.Monic()
+	if f.Degree() < 1 { return nil }
+
+	x := Polynomial{Zero(), One()}
+	xq := x.Mod(f)
+	for i := 0; i < 128; i++ {
+		xq = xq.Mul(xq).Mod(f)
+	}
+	g := Gcd(f, xq.Add(x))
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return splitRoots(g, r)
+}
+
+func splitRoots(g Polynomial, r *rand.Rand) []Element {
+	degree := g.Degree()
+	if degree < 1 { return nil }
+	// g is monic, so x + c has root c
+	if degree == 1 { return []Element{g[0]} }
+
+	for {
+		a := Element{r.Uint64(), r.Uint64()}
+		t := Polynomial{Zero(), a}.Mod(g)
+		trace := t
+		for i := 1; i < 128; i++ {
+			t = t.Mul(t).Mod(g)
+			trace = trace.Add(t)
+		}
+		h := Gcd(g, trace)
+		if h.Degree() > 0 && h.Degree() < degree {
+			quotient, _ := g.DivMod(h)
+			return append(splitRoots(h, r), splitRoots(quotient.Monic(), r)...)
+		}
+	}
+}
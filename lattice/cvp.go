@@ -0,0 +1,38 @@
+package lattice
+
+import (
+	"math/big"
+)
+
+// Approximate closest vector, using Kannan's embedding technique: the basis rows are extended
+// with a 0 coordinate, and the target with a coordinate equal to weight:
+//
+// [ B 0 ]
+// [ t w ]
+//
+// If t = v + e with v in the lattice and e short, then (e, w) is in the new lattice and is
+// likely to be one of its shortest vectors, so LLL should find it. The weight should be about
+// the expected length of the error coordinates: if it is too large, (e, w) is not short, and if
+// it is too small, (0, w) - (e, w) combinations compete with it.
+//
+// Returns the lattice vector v and `false` if no row of the reduced basis ends with +/- weight.
+func ClosestVector(basis []Vector, target Vector, weight *big.Rat) (Vector, bool) {
+	d := len(target)
+	var extended []Vector
+	for _, row := range basis {
+		extended = append(extended, append(row.Copy(), new(big.Rat)))
+	}
+	extended = append(extended, append(target.Copy(), new(big.Rat).Set(weight)))
+
+	negative := new(big.Rat).Neg(weight)
+	for _, row := range LLL(extended, big.NewRat(99, 100)) {
+		last := row[d]
+		if last.Cmp(weight) == 0 {
+			return target.Sub(row[0:d]), true
+		}
+		if last.Cmp(negative) == 0 {
+			return target.Add(row[0:d]), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,72 @@
+package lattice
+
+import (
+	"math/big"
+)
+
+// Hidden number problem attack on DSA and ECDSA signatures with biased nonces.
+//
+// Both schemes compute s = k^-1 * (h + x * r) mod q, where q is the order of the group. If the
+// low l bits of each nonce are zero, k = 2^l * b with 0 <= b < q / 2^l, and:
+//
+// b = t * x + u mod q, with t = r / (s * 2^l) and u = h / (s * 2^l)
+//
+// So for every signature, t_i * x is within q / 2^(l+1) of c_i = q / 2^(l+1) - u_i mod q, which
+// is a closest vector problem in the lattice generated by q * e_i and (t_1, ..., t_n, 1 / 2^l):
+// the vector (t_1 * x - k_1 * q, ..., t_n * x - k_n * q, x / 2^l) is close to
+// (c_1, ..., c_n, q / 2^(l+1)). Each signature leaks about l bits of x, so we need a little more
+// than log2(q) / l of them.
+
+// The values from a signature needed by the attack
+type Sample struct {
+	H *big.Int // message hash, already converted to an integer
+	R *big.Int
+	S *big.Int
+}
+
+// Recovers the private key from signatures whose nonces have their low zeroBits bits set to 0.
+// Returns `false` if the attack fails, usually because there are not enough signatures. The
+// result should be checked against the public key.
+func RecoverBiasedNonceKey(q *big.Int, samples []Sample, zeroBits int) (*big.Int, bool) {
+	n := len(samples)
+	twoL := new(big.Int).Lsh(big.NewInt(1), uint(zeroBits))
+	// All the coordinates are multiplied by 2^(l+1) so that they are integers
+	scale := new(big.Int).Lsh(twoL, 1)
+
+	var basis []Vector
+	for i := 0; i < n; i++ {
+		row := make([]*big.Int, n + 1)
+		for j := range row {
+			row[j] = new(big.Int)
+		}
+		row[i].Mul(q, scale)
+		basis = append(basis, IntVector(row...))
+	}
+
+	tRow := make([]*big.Int, n + 1)
+	target := make([]*big.Int, n + 1)
+	for i, sample := range samples {
+		// 1 / (s * 2^l)
+		inverse := new(big.Int).Mul(sample.S, twoL)
+		inverse = inverse.ModInverse(inverse.Mod(inverse, q), q)
+		if inverse == nil { return nil, false }
+		t := new(big.Int).Mul(sample.R, inverse)
+		t.Mod(t, q)
+		u := new(big.Int).Mul(sample.H, inverse)
+		u.Mod(u, q)
+		tRow[i] = t.Mul(t, scale)
+		// c = q / 2^(l+1) - u, scaled
+		target[i] = new(big.Int).Sub(q, u.Mul(u, scale))
+	}
+	tRow[n] = big.NewInt(2)
+	target[n] = new(big.Int).Set(q)
+	basis = append(basis, IntVector(tRow...))
+
+	v, ok := ClosestVector(basis, IntVector(target...), new(big.Rat).SetInt(q))
+	if !ok { return nil, false }
+	// The last coordinate is x / 2^l, scaled by 2^(l+1)
+	last := v[n]
+	if !last.IsInt() { return nil, false }
+	x := new(big.Int).Quo(last.Num(), big.NewInt(2))
+	return x.Mod(x, q), true
+}
@@ -0,0 +1,107 @@
+package lattice
+
+import (
+	"math/big"
+	"strings"
+)
+
+// Lattices given by a basis of row vectors with rational coordinates. All the arithmetic is
+// exact (math/big), which is slow but avoids the precision problems of floating point LLL.
+
+type Vector []*big.Rat
+
+func NewVector(size int) Vector {
+	output := make(Vector, size)
+	for i := range output {
+		output[i] = new(big.Rat)
+	}
+	return output
+}
+
+// Converts integers to a vector
+func IntVector(values ...*big.Int) Vector {
+	output := make(Vector, len(values))
+	for i, v := range values {
+		output[i] = new(big.Rat).SetInt(v)
+	}
+	return output
+}
+
+func (this Vector) Copy() Vector {
+	output := make(Vector, len(this))
+	for i, v := range this {
+		output[i] = new(big.Rat).Set(v)
+	}
+	return output
+}
+
+func (this Vector) String() string {
+	var values []string
+	for _, v := range this {
+		values = append(values, v.RatString())
+	}
+	return "(" + strings.Join(values, ", ") + ")"
+}
+
+func (this Vector) Dot(other Vector) *big.Rat {
+	output := new(big.Rat)
+	tmp := new(big.Rat)
+	for i := range this {
+		output.Add(output, tmp.Mul(this[i], other[i]))
+	}
+	return output
+}
+
+func (this Vector) Add(other Vector) Vector {
+	output := make(Vector, len(this))
+	for i := range this {
+		output[i] = new(big.Rat).Add(this[i], other[i])
+	}
+	return output
+}
+
+func (this Vector) Sub(other Vector) Vector {
+	output := make(Vector, len(this))
+	for i := range this {
+		output[i] = new(big.Rat).Sub(this[i], other[i])
+	}
+	return output
+}
+
+func (this Vector) Scale(c *big.Rat) Vector {
+	output := make(Vector, len(this))
+	for i := range this {
+		output[i] = new(big.Rat).Mul(this[i], c)
+	}
+	return output
+}
+
+// Returns the nearest integer, rounding halves up
+func Round(x *big.Rat) *big.Int {
+	// floor(x + 1/2), with Euclidean division since the denominator is positive
+	n := new(big.Int).Mul(x.Num(), big.NewInt(2))
+	n.Add(n, x.Denom())
+	return n.Div(n, new(big.Int).Mul(x.Denom(), big.NewInt(2)))
+}
+
+// Gram-Schmidt orthogonalization, without normalization. Returns the orthogonal vectors
+// b*_i and the coefficients mu[i][j] = <b_i, b*_j> / <b*_j, b*_j>, such that
+// b_i = b*_i + sum(j < i, mu[i][j] * b*_j).
+func GramSchmidt(basis []Vector) ([]Vector, [][]*big.Rat) {
+	n := len(basis)
+	orthogonal := make([]Vector, n)
+	mu := make([][]*big.Rat, n)
+	norms := make([]*big.Rat, n)
+	for i := 0; i < n; i++ {
+		mu[i] = make([]*big.Rat, n)
+		v := basis[i].Copy()
+		for j := 0; j < i; j++ {
+			mu[i][j] = new(big.Rat).Quo(basis[i].Dot(orthogonal[j]), norms[j])
+			v = v.Sub(orthogonal[j].Scale(mu[i][j]))
+		}
+		mu[i][i] = big.NewRat(1, 1)
+		orthogonal[i] = v
+		norms[i] = v.Dot(v)
+	}
+	return orthogonal, mu
+}
@@ -0,0 +1,128 @@
+package lattice
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+	"../dsa"
+)
+
+func intBasis(rows ...[]int64) []Vector {
+	var output []Vector
+	for _, row := range rows {
+		var values []*big.Int
+		for _, v := range row {
+			values = append(values, big.NewInt(v))
+		}
+		output = append(output, IntVector(values...))
+	}
+	return output
+}
+
+func TestRound(t *testing.T) {
+	cases := []struct {
+		x *big.Rat
+		expected int64
+	}{
+		{big.NewRat(7, 2), 4}, {big.NewRat(-7, 2), -3}, {big.NewRat(10, 3), 3},
+		{big.NewRat(-10, 3), -3}, {big.NewRat(-11, 3), -4}, {big.NewRat(5, 1), 5},
+	}
+	for _, c := range cases {
+		if r := Round(c.x); r.Int64() != c.expected {
+			t.Errorf("round(%v): expected %d, got %v", c.x, c.expected, r)
+		}
+	}
+}
+
+func TestLLLExample(t *testing.T) {
+	// Example from the Wikipedia article on LLL, with delta = 3/4
+	reduced := LLL(intBasis([]int64{1, 1, 1}, []int64{-1, 0, 2}, []int64{3, 5, 6}), big.NewRat(3, 4))
+	expected := intBasis([]int64{0, 1, 0}, []int64{1, 0, 1}, []int64{-1, 0, 2})
+	for i := range expected {
+		if reduced[i].String() != expected[i].String() {
+			t.Errorf("row %d: expected %v, got %v", i, expected[i], reduced[i])
+		}
+	}
+}
+
+// Checks that the basis is LLL-reduced and has the same volume as the original one
+func checkReduced(t *testing.T, original []Vector, reduced []Vector, delta *big.Rat) {
+	orthogonal, mu := GramSchmidt(reduced)
+	half := big.NewRat(1, 2)
+	for i := range reduced {
+		for j := 0; j < i; j++ {
+			if new(big.Rat).Abs(mu[i][j]).Cmp(half) > 0 {
+				t.Errorf("not size-reduced: mu[%d][%d] = %v", i, j, mu[i][j])
+			}
+		}
+		if i > 0 {
+			bound := new(big.Rat).Mul(mu[i][i - 1], mu[i][i - 1])
+			bound.Sub(delta, bound)
+			bound.Mul(bound, orthogonal[i - 1].Dot(orthogonal[i - 1]))
+			if orthogonal[i].Dot(orthogonal[i]).Cmp(bound) < 0 {
+				t.Errorf("Lovász condition fails for row %d", i)
+			}
+		}
+	}
+	// LLL only applies unimodular operations, so the volume (product of the Gram-Schmidt norms)
+	// doesn't change
+	volume := func(basis []Vector) *big.Rat {
+		output := big.NewRat(1, 1)
+		orthogonal, _ := GramSchmidt(basis)
+		for _, v := range orthogonal {
+			output.Mul(output, v.Dot(v))
+		}
+		return output
+	}
+	if volume(original).Cmp(volume(reduced)) != 0 {
+		t.Errorf("the reduced basis has a different volume")
+	}
+}
+
+func TestLLLRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{2, 5, 10} {
+		var basis []Vector
+		for i := 0; i < n; i++ {
+			row := make([]int64, n)
+			for j := range row {
+				row[j] = r.Int63n(2000000) - 1000000
+			}
+			basis = append(basis, intBasis(row)...)
+		}
+		for _, delta := range []*big.Rat{big.NewRat(3, 4), big.NewRat(99, 100)} {
+			checkReduced(t, basis, LLL(basis, delta), delta)
+		}
+	}
+}
+
+func TestClosestVector(t *testing.T) {
+	basis := intBasis([]int64{1000, 0, 0}, []int64{0, 1000, 0}, []int64{317, 451, 1})
+	// 5 * b3 - b1 - 2 * b2, plus a small error
+	v := basis[2].Scale(big.NewRat(5, 1)).Sub(basis[0]).Sub(basis[1].Scale(big.NewRat(2, 1)))
+	target := v.Add(intBasis([]int64{3, -2, 1})[0])
+	closest, ok := ClosestVector(basis, target, big.NewRat(3, 1))
+	if !ok || closest.String() != v.String() {
+		t.Errorf("expected %v, got %v", v, closest)
+	}
+}
+
+func TestRecoverBiasedNonceKey(t *testing.T) {
+	params := dsa.DefaultParameters()
+	key := dsa.GenerateKey(params)
+	zeroBits := 16
+	r := rand.New(rand.NewSource(2))
+	var samples []Sample
+	for i := 0; i < 14; i++ {
+		h := dsa.HashMessage([]byte{byte(i)})
+		k := new(big.Int).Rand(r, new(big.Int).Rsh(params.Q, uint(zeroBits)))
+		k.Lsh(k, uint(zeroBits))
+		signature, ok := dsa.SignWithNonce(key, h, k)
+		if !ok { continue }
+		samples = append(samples, Sample{h, signature.R, signature.S})
+	}
+	x, ok := RecoverBiasedNonceKey(params.Q, samples, zeroBits)
+	if !ok || x.Cmp(key.X) != 0 {
+		t.Errorf("could not recover the private key, got %v", x)
+	}
+}
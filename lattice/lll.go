@@ -0,0 +1,84 @@
+package lattice
+
+import (
+	"math/big"
+)
+
+// LLL lattice basis reduction (Lenstra, Lenstra, Lovász), following algorithm 2.6.3 in
+// Cohen's "A Course in Computational Algebraic Number Theory". The Gram-Schmidt coefficients
+// are updated after each step instead of being recomputed.
+//
+// The rows of the basis must be linearly independent. delta is usually 3/4 (the original
+// paper) or 0.99, which gives a better reduction but takes longer. The resulting basis is
+// size-reduced (|mu[i][j]| <= 1/2) and satisfies the Lovász condition:
+// |b*_k|^2 >= (delta - mu[k][k-1]^2) * |b*_{k-1}|^2
+//
+// The first vector of the result is then at most 2^((n - 1) / 2) times as long as the
+// shortest vector of the lattice (with delta = 3/4), and much closer in practice.
+func LLL(basis []Vector, delta *big.Rat) []Vector {
+	n := len(basis)
+	b := make([]Vector, n)
+	for i := range basis {
+		b[i] = basis[i].Copy()
+	}
+	if n < 2 { return b }
+
+	orthogonal, mu := GramSchmidt(b)
+	norms := make([]*big.Rat, n) // |b*_i|^2
+	for i := range orthogonal {
+		norms[i] = orthogonal[i].Dot(orthogonal[i])
+	}
+
+	half := big.NewRat(1, 2)
+	tmp := new(big.Rat)
+
+	// b_k = b_k - round(mu[k][l]) * b_l
+	sizeReduce := func(k int, l int) {
+		if tmp.Abs(mu[k][l]).Cmp(half) <= 0 { return }
+		r := new(big.Rat).SetInt(Round(mu[k][l]))
+		b[k] = b[k].Sub(b[l].Scale(r))
+		mu[k][l].Sub(mu[k][l], r)
+		for j := 0; j < l; j++ {
+			mu[k][j].Sub(mu[k][j], tmp.Mul(r, mu[l][j]))
+		}
+	}
+
+	// Swaps b_k and b_{k-1}, and updates mu and the norms
+	swap := func(k int) {
+		b[k], b[k - 1] = b[k - 1], b[k]
+		for j := 0; j < k - 1; j++ {
+			mu[k][j], mu[k - 1][j] = mu[k - 1][j], mu[k][j]
+		}
+		m := new(big.Rat).Set(mu[k][k - 1])
+		norm := new(big.Rat).Mul(m, m)
+		norm.Mul(norm, norms[k - 1])
+		norm.Add(norm, norms[k])
+		mu[k][k - 1] = new(big.Rat).Quo(new(big.Rat).Mul(m, norms[k - 1]), norm)
+		norms[k] = new(big.Rat).Quo(new(big.Rat).Mul(norms[k - 1], norms[k]), norm)
+		norms[k - 1] = norm
+		for i := k + 1; i < n; i++ {
+			t := mu[i][k]
+			mu[i][k] = new(big.Rat).Sub(mu[i][k - 1], new(big.Rat).Mul(m, t))
+			mu[i][k - 1] = new(big.Rat).Add(t, new(big.Rat).Mul(mu[k][k - 1], mu[i][k]))
+		}
+	}
+
+	k := 1
+	for k < n {
+		sizeReduce(k, k - 1)
+		// Lovász condition
+		bound := new(big.Rat).Mul(mu[k][k - 1], mu[k][k - 1])
+		bound.Sub(delta, bound)
+		bound.Mul(bound, norms[k - 1])
+		if norms[k].Cmp(bound) < 0 {
+			swap(k)
+			if k > 1 { k-- }
+		} else {
+			for l := k - 2; l >= 0; l-- {
+				sizeReduce(k, l)
+			}
+			k++
+		}
+	}
+	return b
+}
@@ -0,0 +1,15 @@
+package mdhash
+
+// Length extension. Given H(secret || message) and the length of secret || message, we
+// can compute H(secret || message || glue || extension), where glue is the padding that
+// was appended to secret || message, without knowing the secret. This is because the
+// hash is the chaining state after the padded message, so we can just continue from it.
+//
+// Returns the glue padding and the new hash.
+func LengthExtension(newHash func() *Digest, hash []byte, length uint64, extension []byte) ([]byte, []byte) {
+	h := newHash()
+	glue := h.Padding(length)
+	h.SetStateFromHash(hash, length + uint64(len(glue)))
+	h.Write(extension)
+	return glue, h.Sum(nil)
+}
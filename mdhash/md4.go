@@ -0,0 +1,55 @@
+package mdhash
+
+import (
+	"encoding/binary"
+)
+
+// MD4, as described in RFC 1320. Unlike SHA, the words and length are little-endian.
+
+func NewMD4() *Digest {
+	return newDigest([]uint32{0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476}, 16, false, md4Compress)
+}
+
+func MD4(data []byte) []byte {
+	h := NewMD4()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+var md4Round2Order = []int{0, 4, 8, 12, 1, 5, 9, 13, 2, 6, 10, 14, 3, 7, 11, 15}
+var md4Round3Order = []int{0, 8, 4, 12, 2, 10, 6, 14, 1, 9, 5, 13, 3, 11, 7, 15}
+
+func md4Compress(state []uint32, block []byte) {
+	x := make([]uint32, 16)
+	for i := 0; i < 16; i++ {
+		x[i] = binary.LittleEndian.Uint32(block[i * 4:])
+	}
+
+	a, b, c, d := state[0], state[1], state[2], state[3]
+
+	// Round 1: F(x, y, z) = (x & y) | (^x & z)
+	shifts := []uint{3, 7, 11, 19}
+	for i := 0; i < 16; i++ {
+		a = leftRotate(a + ((b & c) | (^b & d)) + x[i], shifts[i % 4])
+		a, b, c, d = d, a, b, c
+	}
+
+	// Round 2: G(x, y, z) = (x & y) | (x & z) | (y & z)
+	shifts = []uint{3, 5, 9, 13}
+	for i := 0; i < 16; i++ {
+		a = leftRotate(a + ((b & c) | (b & d) | (c & d)) + x[md4Round2Order[i]] + 0x5a827999, shifts[i % 4])
+		a, b, c, d = d, a, b, c
+	}
+
+	// Round 3: H(x, y, z) = x ^ y ^ z
+	shifts = []uint{3, 9, 11, 15}
+	for i := 0; i < 16; i++ {
+		a = leftRotate(a + (b ^ c ^ d) + x[md4Round3Order[i]] + 0x6ed9eba1, shifts[i % 4])
+		a, b, c, d = d, a, b, c
+	}
+
+	state[0] += a
+	state[1] += b
+	state[2] += c
+	state[3] += d
+}
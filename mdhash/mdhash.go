@@ -0,0 +1,126 @@
+package mdhash
+
+import (
+	"encoding/binary"
+)
+
+// Educational implementations of Merkle–Damgård hash functions (SHA-1, SHA-256 and MD4).
+// Unlike the standard library, the internal state (chaining value and number of bytes
+// processed) can be read and set, which is needed for length-extension attacks.
+//
+// Digest implements hash.Hash, so it can be used with crypto/hmac.
+
+type Digest struct {
+	iv []uint32
+	state []uint32
+	buffer []byte
+	length uint64 // Number of bytes processed so far, including the buffer
+	size int
+	bigEndian bool
+	compress func(state []uint32, block []byte)
+}
+
+const BlockSize = 64
+
+func newDigest(iv []uint32, size int, bigEndian bool, compress func(state []uint32, block []byte)) *Digest {
+	output := new(Digest)
+	output.iv = iv
+	output.size = size
+	output.bigEndian = bigEndian
+	output.compress = compress
+	output.Reset()
+	return output
+}
+
+func (this *Digest) byteOrder() binary.ByteOrder {
+	if this.bigEndian { return binary.BigEndian }
+	return binary.LittleEndian
+}
+
+func (this *Digest) Reset() {
+	this.state = append([]uint32{}, this.iv...)
+	this.buffer = nil
+	this.length = 0
+}
+
+func (this *Digest) Size() int {
+	return this.size
+}
+
+func (this *Digest) BlockSize() int {
+	return BlockSize
+}
+
+func (this *Digest) Write(data []byte) (int, error) {
+	this.length += uint64(len(data))
+	this.buffer = append(this.buffer, data...)
+	for len(this.buffer) >= BlockSize {
+		this.compress(this.state, this.buffer[0:BlockSize])
+		this.buffer = this.buffer[BlockSize:]
+	}
+	return len(data), nil
+}
+
+// Appends the hash to b. The current state is not modified, so more data can be written afterwards.
+func (this *Digest) Sum(b []byte) []byte {
+	state := append([]uint32{}, this.state...)
+	data := append([]byte{}, this.buffer...)
+	data = append(data, this.Padding(this.length)...)
+	for i := 0; i < len(data); i += BlockSize {
+		this.compress(state, data[i:i + BlockSize])
+	}
+	output := make([]byte, len(state) * 4)
+	for i, s := range state {
+		this.byteOrder().PutUint32(output[i * 4:], s)
+	}
+	return append(b, output...)
+}
+
+// Returns the chaining state. This is only the state after the last complete block,
+// so it corresponds to the hash only if the number of bytes written is a multiple of
+// the block size (which is the case once the hash has been padded).
+func (this *Digest) State() []uint32 {
+	return append([]uint32{}, this.state...)
+}
+
+// Returns the number of bytes processed so far
+func (this *Digest) Length() uint64 {
+	return this.length
+}
+
+// Sets the chaining state and the number of bytes processed so far, which must be a
+// multiple of the block size. This makes it possible to continue hashing from a known hash.
+func (this *Digest) SetState(state []uint32, length uint64) {
+	this.state = append([]uint32{}, state...)
+	this.buffer = nil
+	this.length = length
+}
+
+// Sets the state from a hash value, as returned by Sum
+func (this *Digest) SetStateFromHash(hash []byte, length uint64) {
+	state := make([]uint32, len(hash) / 4)
+	for i := 0; i < len(state); i++ {
+		state[i] = this.byteOrder().Uint32(hash[i * 4:])
+	}
+	this.SetState(state, length)
+}
+
+// Returns the padding that is appended to a message of the given length: 0x80, then
+// zeros up to 56 bytes modulo 64, then the message length in bits.
+func (this *Digest) Padding(length uint64) []byte {
+	output := []byte{0x80}
+	for (length + uint64(len(output))) % BlockSize != 56 {
+		output = append(output, 0)
+	}
+	lengthBytes := make([]byte, 8)
+	this.byteOrder().PutUint64(lengthBytes, length * 8)
+	return append(output, lengthBytes...)
+}
+
+func leftRotate(x uint32, n uint) uint32 {
+	return x << n | x >> (32 - n)
+}
+
+func rightRotate(x uint32, n uint) uint32 {
+	return x >> n | x << (32 - n)
+}
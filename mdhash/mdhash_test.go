@@ -0,0 +1,113 @@
+package mdhash
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func testData() [][]byte {
+	var output [][]byte
+	for _, length := range []int{0, 1, 3, 55, 56, 63, 64, 65, 119, 120, 200, 1000} {
+		data := make([]byte, length)
+		for i := 0; i < length; i++ {
+			data[i] = byte(i * 7 + length)
+		}
+		output = append(output, data)
+	}
+	return output
+}
+
+func TestSHA1(t *testing.T) {
+	for _, data := range testData() {
+		expected := sha1.Sum(data)
+		if h := SHA1(data); hex.EncodeToString(h) != hex.EncodeToString(expected[:]) {
+			t.Errorf("length %d: %x is different from %x", len(data), h, expected)
+		}
+	}
+}
+
+func TestSHA256(t *testing.T) {
+	for _, data := range testData() {
+		expected := sha256.Sum256(data)
+		if h := SHA256(data); hex.EncodeToString(h) != hex.EncodeToString(expected[:]) {
+			t.Errorf("length %d: %x is different from %x", len(data), h, expected)
+		}
+	}
+}
+
+func TestMD4(t *testing.T) {
+	// Test vectors from RFC 1320
+	vectors := map[string]string{
+		"": "31d6cfe0d16ae931b73c59d7e0c089c0",
+		"a": "bde52cb31de33e46245e05fbdbd6fb24",
+		"abc": "a448017aaf21d8525fc10ae87aa6729d",
+		"message digest": "d9130a8164549fe818874806e1c7014b",
+		"abcdefghijklmnopqrstuvwxyz": "d79e1c308aa5bbcdeea8ed63df412da9",
+		"12345678901234567890123456789012345678901234567890123456789012345678901234567890": "e33b4ddc9c38f2199c3e7b164fcc0536",
+	}
+	for input, expected := range vectors {
+		if h := hex.EncodeToString(MD4([]byte(input))); h != expected {
+			t.Errorf("%q: %s is different from %s", input, h, expected)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	for _, newHash := range []func() *Digest{NewSHA1, NewSHA256, NewMD4} {
+		data := []byte("comment1=cooking%20MCs;userdata=foo;comment2=%20like%20a%20pound%20of%20bacon")
+		h := newHash()
+		h.Write(data)
+		hash := h.Sum(nil)
+
+		// Continuing from the hash gives the same result as hashing the padded data with the extension
+		padded := append(append([]byte{}, data...), h.Padding(uint64(len(data)))...)
+		extended := newHash()
+		extended.SetStateFromHash(hash, uint64(len(padded)))
+		extended.Write([]byte(";admin=true"))
+
+		expected := newHash()
+		expected.Write(padded)
+		expected.Write([]byte(";admin=true"))
+		if hex.EncodeToString(extended.Sum(nil)) != hex.EncodeToString(expected.Sum(nil)) {
+			t.Errorf("size %d: %x is different from %x", h.Size(), extended.Sum(nil), expected.Sum(nil))
+		}
+
+		// The chaining state after the padded data is the hash
+		if expected.Length() != uint64(len(padded)) + 11 {
+			t.Errorf("unexpected length: %d", expected.Length())
+		}
+		s := newHash()
+		s.Write(padded)
+		fromHash := newHash()
+		fromHash.SetStateFromHash(hash, uint64(len(padded)))
+		for i, v := range s.State() {
+			if fromHash.State()[i] != v {
+				t.Errorf("size %d: unexpected state %x", h.Size(), s.State())
+				break
+			}
+		}
+	}
+}
+
+func TestLengthExtension(t *testing.T) {
+	for _, newHash := range []func() *Digest{NewSHA1, NewSHA256, NewMD4} {
+		secret := []byte("YELLOW SUBMARINE")
+		message := []byte("user=alice")
+		h := newHash()
+		h.Write(secret)
+		h.Write(message)
+		hash := h.Sum(nil)
+
+		glue, extendedHash := LengthExtension(newHash, hash, uint64(len(secret) + len(message)), []byte("&admin=true"))
+		expected := newHash()
+		expected.Write(secret)
+		expected.Write(message)
+		expected.Write(glue)
+		expected.Write([]byte("&admin=true"))
+		if hex.EncodeToString(extendedHash) != hex.EncodeToString(expected.Sum(nil)) {
+			t.Errorf("size %d: %x is different from %x", h.Size(), extendedHash, expected.Sum(nil))
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package mdhash
+
+import (
+	"encoding/binary"
+)
+
+// SHA-1, based on the pseudo-code at:
+// http://en.wikipedia.org/wiki/SHA-1#SHA-1_pseudocode
+
+func NewSHA1() *Digest {
+	return newDigest([]uint32{0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476, 0xc3d2e1f0}, 20, true, sha1Compress)
+}
+
+func SHA1(data []byte) []byte {
+	h := NewSHA1()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func sha1Compress(state []uint32, block []byte) {
+	w := make([]uint32, 80)
+	for i := 0; i < 16; i++ {
+		w[i] = binary.BigEndian.Uint32(block[i * 4:])
+	}
+	for i := 16; i < 80; i++ {
+		w[i] = leftRotate(w[i-3] ^ w[i-8] ^ w[i-14] ^ w[i-16], 1)
+	}
+
+	a, b, c, d, e := state[0], state[1], state[2], state[3], state[4]
+	for i := 0; i < 80; i++ {
+		var f, k uint32
+		if i < 20 {
+			f = (b & c) | (^b & d)
+			k = 0x5a827999
+		} else if i < 40 {
+			f = b ^ c ^ d
+			k = 0x6ed9eba1
+		} else if i < 60 {
+			f = (b & c) | (b & d) | (c & d)
+			k = 0x8f1bbcdc
+		} else {
+			f = b ^ c ^ d
+			k = 0xca62c1d6
+		}
+		temp := leftRotate(a, 5) + f + e + k + w[i]
+		e = d
+		d = c
+		c = leftRotate(b, 30)
+		b = a
+		a = temp
+	}
+
+	state[0] += a
+	state[1] += b
+	state[2] += c
+	state[3] += d
+	state[4] += e
+}
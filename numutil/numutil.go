@@ -0,0 +1,64 @@
+package numutil
+
+import (
+	"math/big"
+)
+
+// Number theory helpers shared by the public key packages
+
+var one = big.NewInt(1)
+
+// Extended Euclidean algorithm. Returns gcd(a, b), x and y such that a * x + b * y = gcd(a, b)
+func ExtendedGcd(a *big.Int, b *big.Int) (*big.Int, *big.Int, *big.Int) {
+	oldR, r := new(big.Int).Set(a), new(big.Int).Set(b)
+	oldS, s := big.NewInt(1), big.NewInt(0)
+	oldT, t := big.NewInt(0), big.NewInt(1)
+	for r.Sign() != 0 {
+		q := new(big.Int).Div(oldR, r)
+		oldR, r = r, new(big.Int).Sub(oldR, new(big.Int).Mul(q, r))
+		oldS, s = s, new(big.Int).Sub(oldS, new(big.Int).Mul(q, s))
+		oldT, t = t, new(big.Int).Sub(oldT, new(big.Int).Mul(q, t))
+	}
+	return oldR, oldS, oldT
+}
+
+// Returns a^-1 mod m, or `false` if a and m are not coprime
+func InvMod(a *big.Int, m *big.Int) (*big.Int, bool) {
+	g, x, _ := ExtendedGcd(new(big.Int).Mod(a, m), m)
+	if g.Cmp(one) != 0 { return nil, false }
+	return x.Mod(x, m), true
+}
+
+// Chinese remainder theorem. Returns x such that x = residues[i] mod moduli[i] for all i,
+// with 0 <= x < product of the moduli, which must be pairwise coprime.
+func CRT(residues []*big.Int, moduli []*big.Int) *big.Int {
+	product := big.NewInt(1)
+	for _, m := range moduli {
+		product.Mul(product, m)
+	}
+	output := big.NewInt(0)
+	for i, m := range moduli {
+		ms := new(big.Int).Div(product, m)
+		inverse, _ := InvMod(ms, m)
+		term := new(big.Int).Mul(residues[i], ms)
+		term.Mul(term, inverse)
+		output.Add(output, term)
+	}
+	return output.Mod(output, product)
+}
+
+// Returns the distinct prime factors of n that are smaller than bound, by trial division
+func SmallFactors(n *big.Int, bound int64) []*big.Int {
+	var output []*big.Int
+	rest := new(big.Int).Set(n)
+	r := new(big.Int)
+	for p := int64(2); p < bound && rest.Cmp(one) > 0; p++ {
+		bp := big.NewInt(p)
+		if r.Mod(rest, bp).Sign() != 0 { continue }
+		output = append(output, bp)
+		for r.Mod(rest, bp).Sign() == 0 {
+			rest.Div(rest, bp)
+		}
+	}
+	return output
+}
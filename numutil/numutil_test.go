@@ -0,0 +1,31 @@
+package numutil
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestInvMod(t *testing.T) {
+	d, ok := InvMod(big.NewInt(17), big.NewInt(3120))
+	if !ok || d.Int64() != 2753 {
+		t.Errorf("expected 2753, got %v", d)
+	}
+	if _, ok := InvMod(big.NewInt(6), big.NewInt(9)); ok {
+		t.Errorf("6 should not be invertible mod 9")
+	}
+}
+
+func TestCRT(t *testing.T) {
+	x := CRT([]*big.Int{big.NewInt(2), big.NewInt(3), big.NewInt(2)}, []*big.Int{big.NewInt(3), big.NewInt(5), big.NewInt(7)})
+	if x.Int64() != 23 {
+		t.Errorf("expected 23, got %v", x)
+	}
+}
+
+func TestSmallFactors(t *testing.T) {
+	// 2^3 * 3 * 101 * 1000003
+	factors := SmallFactors(big.NewInt(2424007272), 1000)
+	if len(factors) != 3 || factors[0].Int64() != 2 || factors[1].Int64() != 3 || factors[2].Int64() != 101 {
+		t.Errorf("expected [2 3 101], got %v", factors)
+	}
+}
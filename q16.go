@@ -84,36 +84,20 @@ func main() {
 	ciphertext = encrypt(input)
 	bi := (len(ciphertext) / bs) / 2 // a block index where we know some of our input is
 		
-	// Since the block mode is CBC, we know that our input (assumed it's in "block 2" to simplify) is going to be:
-	// 1. XORed against block 1
-	// 2. Then AES-encrypted
+	// Since the block mode is CBC, when decrypting, block bi + 1 is AES-decrypted and then XORed
+	// against ciphertext block bi. We know that block bi + 1 currently decrypts to our input, so
+	// flipping the bits of ciphertext block bi with input ^ adminString makes it decrypt to
+	// adminString instead.
 	//
-	// When decrypting, block 2 is going to be:
-	// 1. AES-Decrypted.
-	// 2. XORed against block 1
-	//
-	// Basically, on block 2, we currently have:
-	// ciphertextBlock1 ^ input
-	//
-	// We need to create newBlock2 so that, when XORed against (ciphertextBlock1 ^ input), it produces adminString:
-	//
-	// So we want:
-	//
-	// (ciphertextBlock1 ^ input) ^ newBlock2 = adminString
-	//
-	// Which is equivalent to:
-	//
-	// newBlock2 = (ciphertextBlock1 ^ input) ^ adminString	
-	
-	ciphertextBlock1 := ciphertext[bi * bs:bi * bs + bs]
-	newBlock2 := cryptoutil.RepeatingKeyXor([]byte(";admin=true;aaaa"), input)
-	newBlock2 = cryptoutil.RepeatingKeyXor(newBlock2, ciphertextBlock1)
-	
-	// Now that we have the new value for block 1, build the new ciphertext
+	// This is what cryptoutil.CBCBitFlip does. Block bi is scrambled in the process, but since it
+	// contains only our input it doesn't matter.
 	
-	newciphertext := ciphertext[0:bi * bs]
-	newciphertext = cryptoutil.AppendBytes(newciphertext, newBlock2)
-	newciphertext = cryptoutil.AppendBytes(newciphertext, ciphertext[len(newciphertext):len(ciphertext)])
+	newciphertext, _, scrambled, ok := cryptoutil.CBCBitFlip(ciphertext, randomIv, (bi + 1) * bs, input[0:bs], []byte(";admin=true;aaaa"))
+	if !ok {
+		log.Println("Could not flip the bits")
+		return
+	}
+	log.Println("Scrambled block:", scrambled)
 
 	log.Println("Is admin:", isAdmin(newciphertext))
 	log.Println(string(decrypt(newciphertext)))	
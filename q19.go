@@ -4,55 +4,9 @@ import (
 	"log"
 	"./cryptoutil"
 	"./charfreq"
-	"crypto/aes"
-	"bytes"
 	"encoding/base64"
-	"encoding/binary"
 )
 
-// Convert an int to a byte slice
-func Int64ToBytes(i int64, byteOrder binary.ByteOrder) []byte {
-	buffer := new(bytes.Buffer)
-	binary.Write(buffer, byteOrder, i)
-	return buffer.Bytes()
-}
-
-// Create the CTR mode keystream, which is AES(nonce || counter, key)
-func CreateKeystream(key []byte, nonce int64, counter int64) []byte {
-	cypher, _ := aes.NewCipher(key)
-	keystreamKey := Int64ToBytes(nonce, binary.LittleEndian)
-	keystreamKey = cryptoutil.AppendBytes(keystreamKey, Int64ToBytes(counter, binary.LittleEndian))
-	output := make([]byte, 16)
-	cypher.Encrypt(output, keystreamKey)
-	return output
-}
-
-func AESCTRDecrypt(encrypted []byte, key []byte, nonce int64) []byte {
-	output := make([]byte, len(encrypted))
-	keystreamIndex := 0
-	var counter int64 = 0
-	var keystream []byte
-	// Loop through each byte of the encrypted string and XOR it against the
-	// keystream. Generate new keystreams as needed, incrementing the counter
-	// every time.
-	for i := 0; i < len(encrypted); i++ {
-		if i == 0 || keystreamIndex >= 16 {
-			keystream = CreateKeystream(key, nonce, counter)
-			counter++
-			keystreamIndex = 0
-		}
-		b := encrypted[i]
-		output[i] = b ^ keystream[keystreamIndex]
-		keystreamIndex++
-	}
-	
-	return output
-}
-
-func AESCTREncrypt(plaintext []byte, key []byte, nonce int64) []byte {
-	return AESCTRDecrypt(plaintext, key, nonce)
-}
-
 func mostFrequentByte(data []byte) (byte, int) {
 	results := make(map[byte]int)
 	for _, b := range data {
@@ -227,7 +181,7 @@ func main() {
 	key := cryptoutil.RandomBytes(16)
 	for _, line := range data {
 		decoded, _ := base64.StdEncoding.DecodeString(line)
-		ciphertext := AESCTREncrypt(decoded, key, 0)
+		ciphertext := cryptoutil.AES128CTREncrypt(decoded, key, 0)
 		ciphertexts = append(ciphertexts, ciphertext)
 	}
 	
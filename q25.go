@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+	"./cryptoutil"
+	"io/ioutil"
+	"encoding/base64"
+	"encoding/binary"
+)
+
+var randomKey []byte
+var randomNonce int64
+
+// Random-access read/write API, as described in the question. The key and nonce are
+// needed to re-encrypt the edited text.
+func Edit(ciphertext []byte, key []byte, offset int, newtext []byte) []byte {
+	output, _ := cryptoutil.AES128CTREdit(ciphertext, key, randomNonce, offset, newtext)
+	return output
+}
+
+// The edit function exposed to the attacker. The key is not known to the caller.
+func editOracle(ciphertext []byte, offset int, newtext []byte) []byte {
+	return Edit(ciphertext, randomKey, offset, newtext)
+}
+
+func main() {
+	randomKey = cryptoutil.RandomBytes(16)
+	randomNonce = int64(binary.LittleEndian.Uint64(cryptoutil.RandomBytes(8)))
+	
+	// The plaintext is the one from question 7
+	
+	content, _ := ioutil.ReadFile("q7_data.txt")
+	data, _ := base64.StdEncoding.DecodeString(string(content))
+	plaintext := cryptoutil.RemovePkcs7padding(cryptoutil.AES128ECBDecrypt(data, []byte("YELLOW SUBMARINE")))
+	ciphertext := cryptoutil.AES128CTREncrypt(plaintext, randomKey, randomNonce)
+	
+	// Since CTR is just the plaintext XORed against the keystream, editing the ciphertext with some
+	// known bytes gives us the keystream at that offset:
+	//
+	// keystream = newCiphertext ^ knownBytes
+	//
+	// And then the original plaintext is:
+	//
+	// plaintext = ciphertext ^ keystream
+	//
+	// We use zeros as our known bytes so that the new ciphertext is directly the keystream. We could edit the
+	// whole ciphertext in one go but, to show that the edit function seeks in the keystream, we recover
+	// it block by block.
+	
+	bs := 16
+	var recovered []byte
+	for offset := 0; offset < len(ciphertext); offset += bs {
+		length := bs
+		if offset + length > len(ciphertext) { length = len(ciphertext) - offset }
+		newCiphertext := editOracle(ciphertext, offset, cryptoutil.FillBytes(0, length))
+		keystream := newCiphertext[offset:offset + length]
+		recovered = cryptoutil.AppendBytes(recovered, cryptoutil.RepeatingKeyXor(ciphertext[offset:offset + length], keystream))
+	}
+	
+	log.Println("Plaintext recovered:", cryptoutil.SliceEquals(recovered, plaintext))
+	log.Println(string(recovered))
+}
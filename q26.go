@@ -0,0 +1,71 @@
+package main
+
+import (
+	"log"
+	"./cryptoutil"
+	"strings"
+	"net/url"
+	"encoding/binary"
+)
+
+var randomKey []byte
+var randomNonce int64
+
+// Same comment service as in q16, but using CTR mode. ";" and "=" are quoted by url.QueryEscape.
+func encrypt(message []byte) []byte {
+	var plaintext []byte
+	plaintext = cryptoutil.AppendBytes(plaintext, []byte("comment1=cooking%20MCs;userdata="))
+	plaintext = cryptoutil.AppendBytes(plaintext, []byte(url.QueryEscape(string(message))))
+	plaintext = cryptoutil.AppendBytes(plaintext, []byte(";comment2=%20like%20a%20pound%20of%20bacon"))
+	return cryptoutil.AES128CTREncrypt(plaintext, randomKey, randomNonce)
+}
+
+func decrypt(ciphertext []byte) []byte {
+	return cryptoutil.AES128CTRDecrypt(ciphertext, randomKey, randomNonce)
+}
+
+func isAdmin(ciphertext []byte) bool {
+	plaintext := string(decrypt(ciphertext))
+	items := strings.Split(plaintext, ";")
+	for _, item := range items {
+		kv := strings.Split(item, "=")
+		if len(kv) != 2 { continue }
+		k, _ := url.QueryUnescape(kv[0])
+		v, _ := url.QueryUnescape(kv[1])
+		if k == "admin" && v == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+func main() {
+	randomKey = cryptoutil.RandomBytes(16)
+	randomNonce = int64(binary.LittleEndian.Uint64(cryptoutil.RandomBytes(8)))
+	
+	// First, find where our input starts in the ciphertext. Since the prefix is always
+	// encrypted with the same keystream, encrypting two different inputs gives two ciphertexts
+	// that only differ where the input is.
+	
+	ciphertext1 := encrypt([]byte("a"))
+	ciphertext2 := encrypt([]byte("b"))
+	offset := 0
+	for ciphertext1[offset] == ciphertext2[offset] {
+		offset++
+	}
+	
+	// In CTR mode, the plaintext is simply XORed against the keystream, so flipping a bit in the
+	// ciphertext flips the same bit in the plaintext. Unlike with CBC, no block gets scrambled.
+	//
+	// So we input a string of the same length as our target, then XOR it at the right offset with
+	// input ^ adminString
+	
+	desired := []byte(";admin=true")
+	input := cryptoutil.FillBytes('a', len(desired))
+	ciphertext := encrypt(input)
+	newciphertext, _ := cryptoutil.CTRBitFlip(ciphertext, offset, input, desired)
+	
+	log.Println("Input offset:", offset)
+	log.Println("Is admin:", isAdmin(newciphertext))
+	log.Println(string(decrypt(newciphertext)))
+}
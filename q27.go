@@ -0,0 +1,102 @@
+package main
+
+import (
+	"log"
+	"./cryptoutil"
+	"fmt"
+	"strings"
+	"net/url"
+)
+
+var randomKey []byte
+
+// Error returned by the service when the decrypted plaintext is not valid ASCII.
+// Like a careless web server, it includes the offending plaintext.
+type asciiError struct {
+	Plaintext []byte
+}
+
+func (this *asciiError) Error() string {
+	return fmt.Sprintf("Invalid ASCII in plaintext: %q", this.Plaintext)
+}
+
+// Same comment service as in q16, except that the key is also used as the IV.
+func encrypt(message []byte) []byte {
+	var plaintext []byte
+	plaintext = cryptoutil.AppendBytes(plaintext, []byte("comment1=cooking%20MCs;userdata="))
+	plaintext = cryptoutil.AppendBytes(plaintext, []byte(url.QueryEscape(string(message))))
+	plaintext = cryptoutil.AppendBytes(plaintext, []byte(";comment2=%20like%20a%20pound%20of%20bacon"))
+	plaintext = cryptoutil.Pkcs7padding(plaintext, len(plaintext) + cryptoutil.Pkcs7paddingCount(plaintext))
+	return cryptoutil.AES128CBCEncrypt(plaintext, randomKey, randomKey)
+}
+
+func decrypt(ciphertext []byte) ([]byte, error) {
+	plaintext := cryptoutil.AES128CBCDecrypt(ciphertext, randomKey, randomKey)
+	for _, b := range plaintext {
+		if b > 127 {
+			return nil, &asciiError{plaintext}
+		}
+	}
+	return cryptoutil.RemovePkcs7padding(plaintext), nil
+}
+
+func isAdmin(ciphertext []byte) (bool, error) {
+	plaintext, err := decrypt(ciphertext)
+	if err != nil {
+		return false, err
+	}
+	items := strings.Split(string(plaintext), ";")
+	for _, item := range items {
+		kv := strings.Split(item, "=")
+		if len(kv) != 2 { continue }
+		k, _ := url.QueryUnescape(kv[0])
+		v, _ := url.QueryUnescape(kv[1])
+		if k == "admin" && v == "true" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func main() {
+	bs := 16 // block size
+	randomKey = cryptoutil.RandomBytes(bs)
+	
+	// The ciphertext is at least 3 blocks long since the prefix alone is 32 bytes.
+	
+	ciphertext := encrypt([]byte(""))
+	
+	// Build C1, 0, C1 as the new ciphertext. When decrypting:
+	//
+	// P'1 = D(C1) ^ IV = D(C1) ^ key
+	// P'3 = D(C1) ^ 0  = D(C1)
+	//
+	// So P'1 ^ P'3 = key
+	//
+	// The resulting plaintext is random garbage, so it's very likely to contain high-ASCII
+	// characters, in which case the server gives us the plaintext in the error message.
+	
+	c1 := ciphertext[0:bs]
+	newciphertext := cryptoutil.AppendBytes([]byte{}, c1)
+	newciphertext = cryptoutil.AppendBytes(newciphertext, cryptoutil.FillBytes(0, bs))
+	newciphertext = cryptoutil.AppendBytes(newciphertext, c1)
+	newciphertext = cryptoutil.AppendBytes(newciphertext, ciphertext[3 * bs:])
+	
+	_, err := isAdmin(newciphertext)
+	e, ok := err.(*asciiError)
+	if !ok {
+		log.Println("No error returned - try again")
+		return
+	}
+	log.Println(e)
+	
+	p := e.Plaintext
+	key := cryptoutil.RepeatingKeyXor(p[0:bs], p[2 * bs:3 * bs])
+	log.Println("Key recovered:", cryptoutil.SliceEquals(key, randomKey))
+	
+	// Now that we have the key, we can encrypt anything we want
+	
+	forged := cryptoutil.AES128CBCEncrypt(cryptoutil.Pkcs7padding([]byte("comment1=hello;admin=true"), 32), key, key)
+	admin, _ := isAdmin(forged)
+	log.Println("Is admin:", admin)
+}
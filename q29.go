@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log"
+	"math/rand"
+	"time"
+	"strings"
+	"./cryptoutil"
+	"./mdhash"
+)
+
+var randomKey []byte
+
+func sign(newHash func() *mdhash.Digest, message []byte) []byte {
+	return cryptoutil.SecretPrefixMAC(newHash(), randomKey, message)
+}
+
+// The service accepts any message with a valid MAC
+func verify(newHash func() *mdhash.Digest, message []byte, mac []byte) bool {
+	return cryptoutil.SliceEquals(sign(newHash, message), mac)
+}
+
+// Generic length-extension attack. We don't know the key length so we try each of them in
+// the given range, and the service tells us which one is correct.
+func forge(newHash func() *mdhash.Digest, message []byte, mac []byte, extension []byte, minKeyLength int, maxKeyLength int) ([]byte, []byte, bool) {
+	for keyLength := minKeyLength; keyLength <= maxKeyLength; keyLength++ {
+		glue, newMac := mdhash.LengthExtension(newHash, mac, uint64(keyLength + len(message)), extension)
+		newMessage := cryptoutil.AppendBytes([]byte{}, message)
+		newMessage = cryptoutil.AppendBytes(newMessage, glue)
+		newMessage = cryptoutil.AppendBytes(newMessage, extension)
+		if verify(newHash, newMessage, newMac) {
+			return newMessage, newMac, true
+		}
+	}
+	return nil, nil, false
+}
+
+func isAdmin(message []byte) bool {
+	for _, item := range strings.Split(string(message), ";") {
+		if item == "admin=true" {
+			return true
+		}
+	}
+	return false
+}
+
+func main() {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	randomKey = cryptoutil.RandomBytes(1 + r.Intn(64))
+	
+	message := []byte("comment1=cooking%20MCs;userdata=foo;comment2=%20like%20a%20pound%20of%20bacon")
+	
+	hashes := map[string]func() *mdhash.Digest{
+		"SHA-1": mdhash.NewSHA1,
+		"SHA-256": mdhash.NewSHA256,
+		"MD4": mdhash.NewMD4,
+	}
+	
+	for name, newHash := range hashes {
+		mac := sign(newHash, message)
+		newMessage, newMac, ok := forge(newHash, message, mac, []byte(";admin=true"), 0, 128)
+		if !ok {
+			log.Println(name, "- could not forge message")
+			continue
+		}
+		log.Printf("%s - forged MAC %x is valid: %v, is admin: %v", name, newMac, verify(newHash, newMessage, newMac), isAdmin(newMessage))
+		log.Printf("%q", newMessage)
+	}
+}
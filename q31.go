@@ -0,0 +1,149 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+	"./cryptoutil"
+	"./timingleak"
+)
+
+// Artificial delay per byte. The attack works down to a few milliseconds. Below that,
+// more samples are needed and it becomes very slow.
+const delay = 5 * time.Millisecond
+
+const workers = 32 // Number of concurrent requests
+const minRounds = 3 // Number of samples per candidate before making any decision
+const maxRounds = 40
+const finalists = 8 // Number of candidates kept after the first rounds
+
+var client *http.Client
+
+// Sends the signature and returns how long the server took to reply, and whether the signature was valid.
+func measure(url string, file string, signature []byte) (time.Duration, bool) {
+	start := time.Now()
+	response, err := client.Get(url + "/test?file=" + file + "&signature=" + hex.EncodeToString(signature))
+	elapsed := time.Since(start)
+	if err != nil {
+		return elapsed, false
+	}
+	response.Body.Close()
+	return elapsed, response.StatusCode == http.StatusOK
+}
+
+// The median is much less sensitive than the mean to the occasional slow request (GC, scheduling...)
+func median(samples []time.Duration) time.Duration {
+	sorted := append([]time.Duration{}, samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted) / 2]
+}
+
+type candidate struct {
+	value byte
+	samples []time.Duration
+}
+
+// Takes one more sample for each candidate, running the requests concurrently. Returns the
+// correct signature if one of them was accepted by the server.
+func sample(url string, file string, known []byte, signatureLength int, candidates []*candidate) ([]byte, bool) {
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	var valid []byte
+	jobs := make(chan *candidate)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				signature := cryptoutil.AppendBytes(cryptoutil.AppendBytes([]byte{}, known), []byte{c.value})
+				signature = cryptoutil.AppendBytes(signature, cryptoutil.FillBytes(0, signatureLength - len(signature)))
+				elapsed, ok := measure(url, file, signature)
+				mutex.Lock()
+				c.samples = append(c.samples, elapsed)
+				if ok { valid = signature }
+				mutex.Unlock()
+			}
+		}()
+	}
+	for _, c := range candidates {
+		jobs <- c
+	}
+	close(jobs)
+	wg.Wait()
+	return valid, valid != nil
+}
+
+// Finds the next byte of the signature. The correct byte makes the server compare one more
+// byte, so it takes `delay` longer than the others.
+//
+// Decision rule: every candidate is first sampled a few times. Then only the best few are kept
+// and sampled again until the median of the best one is ahead of the second one by at least
+// half the delay.
+//
+// Returns the byte, how long the server took for it and nil. If the server accepted one of the
+// candidates, the signature is already complete: returns its byte, a duration of 0 and the
+// whole valid signature.
+func recoverByte(url string, file string, known []byte, signatureLength int) (byte, time.Duration, []byte) {
+	var candidates []*candidate
+	for i := 0; i < 256; i++ {
+		candidates = append(candidates, &candidate{byte(i), nil})
+	}
+	
+	for round := 0; round < maxRounds; round++ {
+		if valid, ok := sample(url, file, known, signatureLength, candidates); ok {
+			return valid[len(known)], 0, valid
+		}
+		if round + 1 < minRounds { continue }
+		
+		sort.Slice(candidates, func(i, j int) bool { return median(candidates[i].samples) > median(candidates[j].samples) })
+		if median(candidates[0].samples) - median(candidates[1].samples) > delay / 2 {
+			break
+		}
+		if len(candidates) > finalists {
+			candidates = candidates[0:finalists]
+		}
+	}
+	return candidates[0].value, median(candidates[0].samples), nil
+}
+
+func main() {
+	server := timingleak.NewServer(cryptoutil.RandomBytes(16), delay)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client = &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: workers}}
+	
+	file := "foo"
+	signatureLength := 20 // HMAC-SHA1
+	log.Printf("Target signature: %x", server.Sign([]byte(file)))
+	
+	// Recover the signature byte by byte. If a byte is wrong, none of the candidates for the next
+	// byte takes longer than the previous one, since the comparison stops at the wrong byte. In that
+	// case we backtrack and try to recover the previous byte again.
+	
+	var known []byte
+	var timings []time.Duration
+	start := time.Now()
+	for len(known) < signatureLength {
+		b, elapsed, valid := recoverByte(ts.URL, file, known, signatureLength)
+		if valid != nil {
+			known = valid
+			break
+		}
+		if len(timings) > 0 && elapsed < timings[len(timings) - 1] + delay / 4 {
+			log.Printf("Byte %d looks wrong, backtracking", len(known) - 1)
+			known = known[0:len(known) - 1]
+			timings = timings[0:len(timings) - 1]
+			continue
+		}
+		known = append(known, b)
+		timings = append(timings, elapsed)
+		log.Printf("%x (%v)", known, elapsed)
+	}
+	
+	_, ok := measure(ts.URL, file, known)
+	log.Printf("Recovered signature: %x, valid: %v, time: %v", known, ok, time.Since(start))
+}
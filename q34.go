@@ -0,0 +1,67 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"./dh"
+)
+
+// Key-fixing MITM. M replaces both public keys with p, so both sides compute:
+//
+// s = p^x mod p = 0
+//
+// M therefore knows the shared secret and can read all the messages, while A and B
+// still communicate normally.
+func mallory(connA *dh.Conn, connB *dh.Conn) {
+	defer connB.Close()
+	
+	// Relay the negotiation
+	negotiate, ok := connA.Receive()
+	if !ok { return }
+	p := negotiate.P
+	connB.Send(negotiate)
+	ack, ok := connB.Receive()
+	if !ok { return }
+	connA.Send(ack)
+	
+	// Replace the public keys with p
+	_, ok = connA.Receive()
+	if !ok { return }
+	connB.Send(&dh.Message{Type: dh.KEY, Key: p})
+	_, ok = connB.Receive()
+	if !ok { return }
+	connA.Send(&dh.Message{Type: dh.KEY, Key: p})
+	
+	secret := big.NewInt(0)
+	for {
+		data, ok := connA.Receive()
+		if !ok { return }
+		message, _ := dh.DecryptMessage(secret, data.Data)
+		log.Printf("M: intercepted message from A: %q", message)
+		connB.Send(data)
+		
+		data, ok = connB.Receive()
+		if !ok { return }
+		message, _ = dh.DecryptMessage(secret, data.Data)
+		log.Printf("M: intercepted message from B: %q", message)
+		connA.Send(data)
+	}
+}
+
+func main() {
+	// Normal exchange between A and B
+	
+	connA, connB := dh.Pipe()
+	go dh.Bob(connB)
+	ok := dh.Alice(connA, dh.P, dh.G, [][]byte{[]byte("Hello Bob"), []byte("Yellow submarine")})
+	log.Println("Exchange without MITM successful:", ok)
+	
+	// Same exchange with M in the middle
+	
+	connA, connMA := dh.Pipe()
+	connMB, connB := dh.Pipe()
+	go dh.Bob(connB)
+	go mallory(connMA, connMB)
+	ok = dh.Alice(connA, dh.P, dh.G, [][]byte{[]byte("Hello Bob"), []byte("Yellow submarine")})
+	log.Println("Exchange with MITM successful:", ok)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"./dh"
+)
+
+// Returns the secret that works with the given data, among the candidates
+func findSecret(candidates []*big.Int, data []byte) (*big.Int, []byte, bool) {
+	for _, secret := range candidates {
+		message, ok := dh.DecryptMessage(secret, data)
+		if ok {
+			return secret, message, true
+		}
+	}
+	return nil, nil, false
+}
+
+// MITM with malicious group parameters. M replaces g with g' during the negotiation, so
+// that B computes B = g'^b, and replaces A's public key with g', so that B computes:
+//
+// sB = g'^b = B
+//
+// and A computes sA = B^a, which is predictable:
+//
+// g' = 1     => B = 1, sA = 1
+// g' = p     => B = 0, sA = 0
+// g' = p - 1 => B = 1 or p - 1, sA = 1 or p - 1 (we try both)
+//
+// M then decrypts and re-encrypts the messages with the right secret for each side, so
+// A and B don't notice anything.
+func mallory(connA *dh.Conn, connB *dh.Conn, maliciousG func(p *big.Int) *big.Int) {
+	defer connB.Close()
+	
+	negotiate, ok := connA.Receive()
+	if !ok { return }
+	p := negotiate.P
+	g := maliciousG(p)
+	connB.Send(&dh.Message{Type: dh.NEGOTIATE, P: p, G: g})
+	ack, ok := connB.Receive()
+	if !ok { return }
+	connA.Send(ack)
+	
+	_, ok = connA.Receive()
+	if !ok { return }
+	connB.Send(&dh.Message{Type: dh.KEY, Key: g})
+	publicB, ok := connB.Receive()
+	if !ok { return }
+	connA.Send(publicB)
+	
+	secretB := publicB.Key
+	candidatesA := []*big.Int{publicB.Key}
+	if publicB.Key.Cmp(new(big.Int).Sub(p, big.NewInt(1))) == 0 {
+		candidatesA = append(candidatesA, big.NewInt(1))
+	}
+	
+	for {
+		data, ok := connA.Receive()
+		if !ok { return }
+		secretA, message, ok := findSecret(candidatesA, data.Data)
+		if !ok {
+			log.Println("M: could not decrypt message from A")
+			return
+		}
+		log.Printf("M: intercepted message from A: %q (sA = %v)", message, secretA)
+		connB.Send(&dh.Message{Type: dh.DATA, Data: dh.EncryptMessage(secretB, message)})
+		
+		data, ok = connB.Receive()
+		if !ok { return }
+		message, _ = dh.DecryptMessage(secretB, data.Data)
+		log.Printf("M: intercepted message from B: %q (sB = %v)", message, secretB)
+		connA.Send(&dh.Message{Type: dh.DATA, Data: dh.EncryptMessage(secretA, message)})
+	}
+}
+
+func main() {
+	attacks := []struct {
+		name string
+		g func(p *big.Int) *big.Int
+	}{
+		{"g = 1", func(p *big.Int) *big.Int { return big.NewInt(1) }},
+		{"g = p", func(p *big.Int) *big.Int { return new(big.Int).Set(p) }},
+		{"g = p - 1", func(p *big.Int) *big.Int { return new(big.Int).Sub(p, big.NewInt(1)) }},
+	}
+	
+	for _, attack := range attacks {
+		log.Println("# Attack with", attack.name)
+		connA, connMA := dh.Pipe()
+		connMB, connB := dh.Pipe()
+		go dh.Bob(connB)
+		go mallory(connMA, connMB, attack.g)
+		ok := dh.Alice(connA, dh.P, dh.G, [][]byte{[]byte("Hello Bob"), []byte("Yellow submarine")})
+		log.Println("Exchange successful:", ok)
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"log"
+	"net/http/httptest"
+	"./srp"
+)
+
+func main() {
+	server := srp.NewServer()
+	server.Register("alice@example.com", []byte("correct horse battery staple"))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	
+	log.Println("Login with correct password:", srp.Login(ts.URL, "alice@example.com", []byte("correct horse battery staple")))
+	log.Println("Login with wrong password:", srp.Login(ts.URL, "alice@example.com", []byte("incorrect horse battery staple")))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"net/http/httptest"
+	"./srp"
+)
+
+// Logs in without the password by sending the given value as A
+func zeroKeyLogin(serverURL string, identity string, a *big.Int) bool {
+	salt, _, ok := srp.StartLogin(serverURL, identity, a)
+	if !ok { return false }
+	
+	// The server computes S = (A * v^u)^b mod N. If A is 0 modulo N, S is 0 whatever
+	// the verifier is, so K = H(0) and we can compute the HMAC.
+	
+	s := big.NewInt(0)
+	return srp.FinishLogin(serverURL, identity, srp.HMAC(srp.Hash(s.Bytes()), salt))
+}
+
+func main() {
+	server := srp.NewServer()
+	server.Register("alice@example.com", []byte("correct horse battery staple"))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	
+	values := []struct {
+		name string
+		a *big.Int
+	}{
+		{"A = 0", big.NewInt(0)},
+		{"A = N", new(big.Int).Set(srp.N)},
+		{"A = 2N", new(big.Int).Mul(srp.N, big.NewInt(2))},
+	}
+	
+	for _, v := range values {
+		log.Printf("Login with %s: %v", v.name, zeroKeyLogin(ts.URL, "alice@example.com", v.a))
+	}
+	
+	// A server that checks A rejects these values
+	
+	server.ValidateA = true
+	for _, v := range values {
+		log.Printf("Login with %s (validating server): %v", v.name, zeroKeyLogin(ts.URL, "alice@example.com", v.a))
+	}
+}
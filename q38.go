@@ -0,0 +1,84 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"crypto/hmac"
+	"./srp"
+)
+
+// A malicious server implementing the simplified SRP protocol. It doesn't know the password,
+// but it chooses its parameters so that it can check password guesses offline:
+//
+// salt = "", b = 1, B = g, u = 1
+//
+// The client then computes S = B^(a + u * x) = g^a * g^x = A * v mod N. So for each
+// candidate password we can compute v and check the HMAC that the client sent us.
+type mitmServer struct {
+	a *big.Int
+	mac []byte
+	done chan bool
+}
+
+func (this *mitmServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/start":
+		this.a, _ = new(big.Int).SetString(r.FormValue("A"), 16)
+		json.NewEncoder(w).Encode(struct { Salt, B, U string }{"", srp.G.Text(16), "1"})
+	case "/verify":
+		this.mac, _ = hex.DecodeString(r.FormValue("mac"))
+		// Pretend the login failed
+		http.Error(w, "Invalid password", http.StatusForbidden)
+		this.done <- true
+	}
+}
+
+func crack(a *big.Int, mac []byte, words []string) (string, bool) {
+	for _, word := range words {
+		x := srp.PasswordHash([]byte{}, []byte(word))
+		v := new(big.Int).Exp(srp.G, x, srp.N)
+		s := new(big.Int).Mul(a, v)
+		s.Mod(s, srp.N)
+		if hmac.Equal(mac, srp.HMAC(srp.Hash(s.Bytes()), []byte{})) {
+			return word, true
+		}
+	}
+	return "", false
+}
+
+func main() {
+	content, _ := ioutil.ReadFile("q38_data.txt")
+	var words []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" { words = append(words, line) }
+	}
+	password := "matasano"
+	
+	// Login to a legitimate server
+	
+	server := srp.NewSimplifiedServer()
+	server.Register("alice@example.com", []byte(password))
+	ts := httptest.NewServer(server)
+	log.Println("Login to legitimate server:", srp.SimplifiedLogin(ts.URL, "alice@example.com", []byte(password)))
+	ts.Close()
+	
+	// The MITM takes the place of the server and captures A and the HMAC
+	
+	mitm := &mitmServer{done: make(chan bool, 1)}
+	ts = httptest.NewServer(mitm)
+	defer ts.Close()
+	log.Println("Login to MITM server:", srp.SimplifiedLogin(ts.URL, "alice@example.com", []byte(password)))
+	<-mitm.done
+	
+	// Then runs the dictionary attack offline
+	
+	found, ok := crack(mitm.a, mitm.mac, words)
+	log.Printf("Password found: %v, %q (%d words in dictionary)", ok, found, len(words))
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"./rsa"
+)
+
+func main() {
+	message := []byte("Yellow submarine, broadcast to three recipients")
+	m := new(big.Int).SetBytes(message)
+	
+	// The same message is encrypted with e = 3 under three different public keys
+	
+	var ciphertexts []*big.Int
+	var moduli []*big.Int
+	for i := 0; i < 3; i++ {
+		key := rsa.GenerateKey(1024, 3)
+		ciphertexts = append(ciphertexts, rsa.Encrypt(&key.PublicKey, m))
+		moduli = append(moduli, key.N)
+	}
+	
+	// We have c_i = m^3 mod n_i. Using the CRT we can compute m^3 mod n_0 * n_1 * n_2, and since
+	// m < n_i, m^3 is less than n_0 * n_1 * n_2. So the result is m^3 itself, not reduced, and we
+	// just need to take its cube root.
+	
+	cube := rsa.CRT(ciphertexts, moduli)
+	root, exact := rsa.CubeRoot(cube)
+	log.Println("Exact cube root:", exact)
+	log.Println("Decrypted message:", string(root.Bytes()))
+}
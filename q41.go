@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"crypto/rand"
+	"encoding/hex"
+	"./rsa"
+	"./mdhash"
+)
+
+var key *rsa.PrivateKey
+var seen = make(map[string]bool)
+
+// Decrypts any ciphertext, but only once. The server keeps the hashes of the ciphertexts
+// it has already decrypted and refuses to decrypt them again.
+func decryptOnce(c *big.Int) (*big.Int, bool) {
+	h := hex.EncodeToString(mdhash.SHA256(c.Bytes()))
+	if seen[h] {
+		return nil, false
+	}
+	seen[h] = true
+	return rsa.Decrypt(key, c), true
+}
+
+func main() {
+	key = rsa.GenerateKey(1024, 65537)
+	
+	// A client sends an encrypted message, which the server decrypts
+	
+	message := []byte(`{time: 1356304276, social: '555-55-5555'}`)
+	c := rsa.Encrypt(&key.PublicKey, new(big.Int).SetBytes(message))
+	decryptOnce(c)
+	
+	// We capture the ciphertext, but the server won't decrypt it again
+	
+	_, ok := decryptOnce(c)
+	log.Println("Server accepts the same ciphertext again:", ok)
+	
+	// So we blind it with a random S:
+	//
+	// C' = S^e * C mod N
+	//
+	// The server decrypts C' to P' = (S^e * C)^d = S * P mod N, so P = P' * S^-1 mod N
+	
+	n := key.N
+	s, _ := rand.Int(rand.Reader, n)
+	blinded := rsa.Encrypt(&key.PublicKey, s)
+	blinded.Mul(blinded, c)
+	blinded.Mod(blinded, n)
+	
+	p, ok := decryptOnce(blinded)
+	log.Println("Server accepts the blinded ciphertext:", ok)
+	
+	sInverse, _ := rsa.InvMod(s, n)
+	p.Mul(p, sInverse)
+	p.Mod(p, n)
+	log.Println("Recovered message:", string(p.Bytes()))
+}
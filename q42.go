@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/hex"
+	"log"
+	"math/big"
+	"./cryptoutil"
+	"./mdhash"
+	"./rsa"
+)
+
+func main() {
+	key := rsa.GenerateKey(1024, 3)
+	message := []byte("hi mom")
+	digest := mdhash.SHA1(message)
+	
+	// A legitimate signature is accepted by both verifiers
+	
+	signature, _ := rsa.SignPkcs1v15(key, cryptoutil.DigestInfoSHA1, digest)
+	log.Println("Genuine signature, strict verifier:", rsa.VerifyPkcs1v15(&key.PublicKey, cryptoutil.DigestInfoSHA1, digest, signature))
+	log.Println("Genuine signature, sloppy verifier:", rsa.VerifyPkcs1v15Sloppy(&key.PublicKey, cryptoutil.DigestInfoSHA1, digest, signature))
+	
+	// Now we forge a signature without the private key. The sloppy verifier checks:
+	//
+	// 00 01 ff ... ff 00 ASN.1 HASH
+	//
+	// but doesn't check that the hash is at the end of the block. So with a single 0xff byte we
+	// get 00 01 ff 00 ASN.1 HASH, which is only 39 bytes with SHA-1, followed by 89 bytes that
+	// can be anything. Cubing a number close to the cube root of that block gives a value that
+	// matches on the 39 first bytes, and only differs in the garbage.
+	
+	forged, ok := rsa.ForgePkcs1v15Signature(&key.PublicKey, cryptoutil.DigestInfoSHA1, digest)
+	if !ok {
+		log.Println("Could not forge a signature")
+		return
+	}
+	block := new(big.Int).Exp(new(big.Int).SetBytes(forged), big.NewInt(3), nil).FillBytes(make([]byte, 128))
+	log.Println("Forged signature, cubed:", hex.EncodeToString(block))
+	log.Println("Forged signature, strict verifier:", rsa.VerifyPkcs1v15(&key.PublicKey, cryptoutil.DigestInfoSHA1, digest, forged))
+	log.Println("Forged signature, sloppy verifier:", rsa.VerifyPkcs1v15Sloppy(&key.PublicKey, cryptoutil.DigestInfoSHA1, digest, forged))
+	
+	// The same works with SHA-256, which adds 16 bytes to the prefix, but the key must be larger
+	// since the garbage has to be about 2/3 of the block.
+	
+	key = rsa.GenerateKey(2048, 3)
+	digest = mdhash.SHA256(message)
+	forged, ok = rsa.ForgePkcs1v15Signature(&key.PublicKey, cryptoutil.DigestInfoSHA256, digest)
+	if !ok {
+		log.Println("Could not forge a SHA-256 signature")
+		return
+	}
+	log.Println("Forged SHA-256 signature, strict verifier:", rsa.VerifyPkcs1v15(&key.PublicKey, cryptoutil.DigestInfoSHA256, digest, forged))
+	log.Println("Forged SHA-256 signature, sloppy verifier:", rsa.VerifyPkcs1v15Sloppy(&key.PublicKey, cryptoutil.DigestInfoSHA256, digest, forged))
+}
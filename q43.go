@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/hex"
+	"log"
+	"math/big"
+	"./dsa"
+	"./mdhash"
+)
+
+func main() {
+	params := dsa.DefaultParameters()
+	
+	// If we know the nonce used for a signature, we can compute the private key:
+	// x = (s * k - H(m)) / r mod q
+	
+	key := dsa.GenerateKey(params)
+	k := big.NewInt(0xcafe)
+	h := dsa.HashMessage([]byte("hi mom"))
+	signature, _ := dsa.SignWithNonce(key, h, k)
+	x, _ := dsa.RecoverPrivateKey(params, h, signature, k)
+	log.Println("Private key recovered from a known nonce:", x.Cmp(key.X) == 0)
+	
+	// If the nonce is small enough, we can just try all the values. We have a public key and a
+	// signature made with a nonce between 0 and 2^16.
+	
+	y, _ := new(big.Int).SetString("84ad4719d044495496a3201c8ff484feb45b962e7302e56a392aee4abab3e4bdebf2955b4736012f21a08084056b19bcd7fee56048e004e44984e2f411788efdc837a0d2e5abb7b555039fd243ac01f0fb2ed1dec568280ce678e931868d23eb095fde9d3779191b8c0299d6e07bbb283e6633451e535c45513b2d33c99ea17", 16)
+	publicKey := &dsa.PublicKey{Parameters: *params, Y: y}
+	message := []byte("For those that envy a MC it can be hazardous to your health\nSo be friendly, a matter of life and death, just like a etch-a-sketch\n")
+	r, _ := new(big.Int).SetString("548099063082341131477253921760299949438196259240", 10)
+	s, _ := new(big.Int).SetString("857042759984254168557880549501802188789837994940", 10)
+	signature = &dsa.Signature{R: r, S: s}
+	h = dsa.HashMessage(message)
+	log.Printf("H(m) = %x", h)
+	log.Println("Signature valid:", dsa.Verify(publicKey, message, signature))
+	
+	// For each candidate nonce, r = (g^k mod p) mod q tells us whether it's the right one, without
+	// having to compute g^x for each candidate private key.
+	
+	for i := int64(1); i < 1 << 16; i++ {
+		k := big.NewInt(i)
+		rk := new(big.Int).Exp(params.G, k, params.P)
+		if rk.Mod(rk, params.Q).Cmp(r) != 0 { continue }
+		x, _ := dsa.RecoverPrivateKey(params, h, signature, k)
+		log.Println("Found nonce:", k)
+		log.Println("Private key:", x)
+		log.Println("Private key valid:", dsa.IsPrivateKey(publicKey, x))
+		log.Println("SHA-1 of the private key in hex:", hex.EncodeToString(mdhash.SHA1([]byte(x.Text(16)))))
+		return
+	}
+	log.Println("Nonce not found")
+}
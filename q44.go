@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"./dsa"
+)
+
+type signedMessage struct {
+	message []byte
+	signature *dsa.Signature
+}
+
+// A broken signer that picks its nonces from a small pool, so some of them end up being reused
+func corpus(key *dsa.PrivateKey, count int) []signedMessage {
+	var nonces []*big.Int
+	for i := 0; i < 4; i++ {
+		k, _ := rand.Int(rand.Reader, key.Q)
+		nonces = append(nonces, k)
+	}
+	var output []signedMessage
+	for i := 0; i < count; i++ {
+		message := []byte(fmt.Sprintf("Message number %d: listen up, this is serious", i))
+		j, _ := rand.Int(rand.Reader, big.NewInt(int64(len(nonces))))
+		signature, ok := dsa.SignWithNonce(key, dsa.HashMessage(message), nonces[j.Int64()])
+		if !ok { continue }
+		output = append(output, signedMessage{message, signature})
+	}
+	return output
+}
+
+func main() {
+	params := dsa.DefaultParameters()
+	key := dsa.GenerateKey(params)
+	messages := corpus(key, 12)
+	log.Println("Signed messages:", len(messages))
+	
+	// r only depends on the nonce, so two signatures with the same r share the same nonce. Then:
+	// s1 - s2 = k^-1 * (H(m1) - H(m2)), so k = (H(m1) - H(m2)) / (s1 - s2) mod q
+	// and the private key follows from the nonce.
+	
+	byR := make(map[string]signedMessage)
+	for _, m := range messages {
+		previous, found := byR[m.signature.R.String()]
+		if !found {
+			byR[m.signature.R.String()] = m
+			continue
+		}
+		h1 := dsa.HashMessage(previous.message)
+		h2 := dsa.HashMessage(m.message)
+		k, ok := dsa.RecoverNonce(params, h1, previous.signature, h2, m.signature)
+		if !ok { continue }
+		x, ok := dsa.RecoverPrivateKey(params, h1, previous.signature, k)
+		if !ok || !dsa.IsPrivateKey(&key.PublicKey, x) { continue }
+		log.Printf("Repeated nonce in \"%s\" and \"%s\"", previous.message, m.message)
+		log.Println("Private key:", x)
+		log.Println("Matches the real key:", x.Cmp(key.X) == 0)
+		return
+	}
+	log.Println("No repeated nonce found")
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"./dsa"
+)
+
+func main() {
+	params := dsa.DefaultParameters()
+	messages := [][]byte{[]byte("Hello, world"), []byte("Goodbye, world")}
+	
+	// With g = 0, y = 0 and v = (g^u1 * y^u2 mod p) mod q = 0 for any signature. So r = 0 with
+	// any s verifies for any message, but only with a verifier that doesn't check that r > 0.
+	
+	bad := *params
+	bad.G = big.NewInt(0)
+	key := dsa.GenerateKey(&bad)
+	forged := &dsa.Signature{R: big.NewInt(0), S: big.NewInt(12345)}
+	for _, message := range messages {
+		log.Printf("g = 0, \"%s\": sloppy verifier %v, strict verifier %v", message,
+			dsa.VerifySloppy(&key.PublicKey, message, forged), dsa.Verify(&key.PublicKey, message, forged))
+	}
+	
+	// With g = p + 1, g^n = 1 mod p for any n, so v = 1 for any signature and r = 1 verifies. But
+	// this time a strict verifier accepts it too. We can also make a "magic signature" that
+	// looks more legitimate, for any public key y and any z:
+	//
+	// r = (y^z mod p) mod q
+	// s = r / z mod q
+	//
+	// Then u2 = r / s = z and v = (y^z mod p) mod q = r.
+	
+	bad = *params
+	bad.G = new(big.Int).Add(params.P, big.NewInt(1))
+	key = dsa.GenerateKey(&bad)
+	z := big.NewInt(42)
+	r := new(big.Int).Exp(key.Y, z, params.P)
+	r.Mod(r, params.Q)
+	s := new(big.Int).ModInverse(z, params.Q)
+	s.Mul(s, r)
+	s.Mod(s, params.Q)
+	magic := &dsa.Signature{R: r, S: s}
+	for _, message := range messages {
+		log.Printf("g = p + 1, \"%s\": strict verifier %v", message, dsa.Verify(&key.PublicKey, message, magic))
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"encoding/base64"
+	"./rsa"
+)
+
+var key *rsa.PrivateKey
+var queries int
+
+// Tells whether the plaintext is even or odd
+func parityOracle(c *big.Int) bool {
+	queries++
+	return rsa.Decrypt(key, c).Bit(0) == 0
+}
+
+func main() {
+	key = rsa.GenerateKey(1024, 65537)
+	message, _ := base64.StdEncoding.DecodeString("VGhhdCdzIHdoeSBJIGZvdW5kIHlvdSBkb24ndCBwbGF5IGFyb3VuZCB3aXRoIHRoZSBGdW5reSBDb2xkIE1lZGluYQ==")
+	c := rsa.Encrypt(&key.PublicKey, new(big.Int).SetBytes(message))
+	
+	// Multiplying the ciphertext by 2^e multiplies the plaintext by 2. Since n is odd, 2m mod n is
+	// even if 2m < n (no wrap around) and odd if 2m >= n. So each query tells us in which half of
+	// the current interval the plaintext is:
+	//
+	// After k queries, m is in [a * n / 2^k, (a + 1) * n / 2^k)
+	//
+	// We keep a as an exact integer to avoid rounding errors. After log2(n) queries the interval
+	// is less than 1 wide and contains only m.
+	
+	n := key.N
+	two := rsa.Encrypt(&key.PublicKey, big.NewInt(2))
+	a := big.NewInt(0)
+	k := uint(0)
+	for k < uint(n.BitLen()) {
+		c.Mul(c, two)
+		c.Mod(c, n)
+		a.Lsh(a, 1)
+		if !parityOracle(c) {
+			a.Add(a, big.NewInt(1))
+		}
+		k++
+		
+		// Upper bound: (a + 1) * n / 2^k
+		upper := new(big.Int).Add(a, big.NewInt(1))
+		upper.Mul(upper, n)
+		upper.Rsh(upper, k)
+		log.Printf("%q", upper.Bytes())
+	}
+	
+	// m = ceil(a * n / 2^k)
+	m := new(big.Int).Mul(a, n)
+	m.Add(m, new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), k), big.NewInt(1)))
+	m.Rsh(m, k)
+	log.Printf("Recovered message: %q", m.Bytes())
+	log.Println("Oracle queries:", queries)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"./cryptoutil"
+	"./rsa"
+	"./bleichenbacher"
+)
+
+var key *rsa.PrivateKey
+
+// Decrypts the ciphertext and tells whether the plaintext starts with 0x00 0x02
+func paddingOracle(c *big.Int) bool {
+	k := (key.N.BitLen() + 7) / 8
+	return cryptoutil.IsPkcs1v15Conforming(rsa.Decrypt(key, c).FillBytes(make([]byte, k)), k)
+}
+
+func main() {
+	key = rsa.GenerateKey(256, 3)
+	k := (key.N.BitLen() + 7) / 8
+	
+	padded, _ := cryptoutil.Pkcs1v15Padding([]byte("kick it, CC"), k)
+	c := rsa.Encrypt(&key.PublicKey, new(big.Int).SetBytes(padded))
+	log.Println("Ciphertext is conforming:", paddingOracle(c))
+	
+	m, queries := bleichenbacher.Attack(&key.PublicKey, c, paddingOracle, 1)
+	message, _ := cryptoutil.RemovePkcs1v15Padding(m.FillBytes(make([]byte, k)), k)
+	log.Printf("Recovered message: %q", message)
+	log.Println("Oracle queries:", queries)
+}
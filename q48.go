@@ -0,0 +1,37 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"runtime"
+	"time"
+	"./cryptoutil"
+	"./rsa"
+	"./bleichenbacher"
+)
+
+var key *rsa.PrivateKey
+
+// Same oracle as in q47. It doesn't modify any shared state so it can be called concurrently.
+func paddingOracle(c *big.Int) bool {
+	k := (key.N.BitLen() + 7) / 8
+	return cryptoutil.IsPkcs1v15Conforming(rsa.Decrypt(key, c).FillBytes(make([]byte, k)), k)
+}
+
+func main() {
+	// Same as q47 with a bigger modulus. Most of the time is spent in step 2a and 2b, looking for
+	// conforming values of s, so we query the oracle concurrently with one worker per CPU.
+	// The attack also works with 1024- and 2048-bit moduli, but takes much longer.
+	
+	key = rsa.GenerateKey(768, 3)
+	k := (key.N.BitLen() + 7) / 8
+	
+	padded, _ := cryptoutil.Pkcs1v15Padding([]byte("kick it, CC"), k)
+	c := rsa.Encrypt(&key.PublicKey, new(big.Int).SetBytes(padded))
+	
+	start := time.Now()
+	m, queries := bleichenbacher.Attack(&key.PublicKey, c, paddingOracle, runtime.NumCPU())
+	message, _ := cryptoutil.RemovePkcs1v15Padding(m.FillBytes(make([]byte, k)), k)
+	log.Printf("Recovered message: %q", message)
+	log.Printf("Oracle queries: %d, time: %v", queries, time.Since(start))
+}
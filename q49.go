@@ -0,0 +1,115 @@
+package main
+
+import (
+	"log"
+	"./cryptoutil"
+	"strings"
+)
+
+var randomKey []byte
+
+// ----------------------------------------------------------------------------
+// Part 1: the IV is controlled by the client
+// ----------------------------------------------------------------------------
+
+// The client signs transfers on behalf of the logged in user, so the attacker can only
+// sign messages from their own account. The request is message || IV || MAC.
+func signTransfer(from string, to string, amount string) []byte {
+	message := []byte("from=" + from + "&to=" + to + "&amount=" + amount)
+	iv := cryptoutil.RandomBytes(16)
+	output := cryptoutil.AppendBytes(message, iv)
+	return cryptoutil.AppendBytes(output, cryptoutil.AES128CBCMAC(message, randomKey, iv))
+}
+
+func processTransfer(request []byte) {
+	message := request[0:len(request) - 32]
+	iv := request[len(request) - 32:len(request) - 16]
+	mac := request[len(request) - 16:]
+	if !cryptoutil.SliceEquals(cryptoutil.AES128CBCMAC(message, randomKey, iv), mac) {
+		log.Println("Invalid MAC")
+		return
+	}
+	log.Println("Valid transfer:", string(message))
+}
+
+// ----------------------------------------------------------------------------
+// Part 2: the IV is fixed, but the messages have a variable length
+// ----------------------------------------------------------------------------
+
+// The request is message || MAC, and the message can contain several transactions.
+func signTransactions(from string, transactions string) []byte {
+	message := []byte("from=" + from + "&tx_list=" + transactions)
+	return cryptoutil.AppendBytes(message, cryptoutil.AES128CBCMAC(message, randomKey, make([]byte, 16)))
+}
+
+func processTransactions(request []byte) {
+	message := request[0:len(request) - 16]
+	mac := request[len(request) - 16:]
+	if !cryptoutil.SliceEquals(cryptoutil.AES128CBCMAC(message, randomKey, make([]byte, 16)), mac) {
+		log.Println("Invalid MAC")
+		return
+	}
+	s := string(message)
+	from := s[len("from="):strings.Index(s, "&")]
+	txList := s[strings.Index(s, "&tx_list=") + len("&tx_list="):]
+	for _, tx := range strings.Split(txList, ";") {
+		log.Printf("Valid transaction from %s: %q", from, tx)
+	}
+}
+
+func main() {
+	bs := 16 // block size
+	randomKey = cryptoutil.RandomBytes(bs)
+	victim := "1"
+	attacker := "3"
+	
+	// # Part 1
+	//
+	// The first block of the message is XORed with the IV before being encrypted. So if we
+	// modify the first block, we can modify the IV in the same way and the MAC is unchanged:
+	//
+	// IV' ^ P1' = IV ^ P1
+	// IV' = IV ^ P1 ^ P1'
+	//
+	// The attacker signs a transfer from their own account to their own account, then
+	// changes the "from" field, which is in the first block.
+	
+	request := signTransfer(attacker, attacker, "1000000")
+	message := request[0:len(request) - 32]
+	iv := request[len(request) - 32:len(request) - 16]
+	mac := request[len(request) - 16:]
+	
+	newMessage := cryptoutil.AppendBytes([]byte{}, message)
+	newMessage[len("from=")] = victim[0]
+	newIv := cryptoutil.RepeatingKeyXor(iv, cryptoutil.RepeatingKeyXor(message[0:bs], newMessage[0:bs]))
+	
+	forged := cryptoutil.AppendBytes(newMessage, newIv)
+	forged = cryptoutil.AppendBytes(forged, mac)
+	processTransfer(forged)
+	
+	// # Part 2
+	//
+	// We capture a valid message from the victim, M1 with MAC t1. The CBC state after M1 is t1,
+	// so if we append a message M2 whose first block is XORed with t1, the CBC state after that
+	// block is the same as if M2 had been MACed on its own. So:
+	//
+	// MAC(M1 || pad(M1) || (M2[0] ^ t1) || M2[1:]) = MAC(M2)
+	//
+	// M2 is signed by the attacker from their own account. Its first block is scrambled by the
+	// XOR, but the rest is appended as is to the victim's transaction list.
+	
+	captured := signTransactions(victim, "2:100;4:250")
+	m1 := captured[0:len(captured) - 16]
+	t1 := captured[len(captured) - 16:]
+	
+	request2 := signTransactions(attacker, attacker + ":1;" + attacker + ":1000000")
+	m2 := request2[0:len(request2) - 16]
+	t2 := request2[len(request2) - 16:]
+	
+	forged = cryptoutil.AppendBytes([]byte{}, m1)
+	forged = cryptoutil.Pkcs7padding(forged, len(forged) + cryptoutil.Pkcs7paddingCount(forged))
+	forged = cryptoutil.AppendBytes(forged, cryptoutil.RepeatingKeyXor(m2[0:bs], t1))
+	forged = cryptoutil.AppendBytes(forged, m2[bs:])
+	forged = cryptoutil.AppendBytes(forged, t2)
+	processTransactions(forged)
+}
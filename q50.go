@@ -0,0 +1,57 @@
+package main
+
+import (
+	"log"
+	"./cryptoutil"
+	"bytes"
+)
+
+// CBC-MAC used as a hash function, with a known key and a zero IV.
+func hash(message []byte) []byte {
+	return cryptoutil.AES128CBCMAC(message, []byte("YELLOW SUBMARINE"), make([]byte, 16))
+}
+
+func main() {
+	bs := 16 // block size
+	original := []byte("alert('MZA who was that?');\n")
+	target := hash(original)
+	log.Printf("Original hash: %x", target)
+	
+	// Since the key is known, we can compute the CBC state after any prefix. We then append a
+	// "glue" block that brings the CBC state back to what it is at the start of the original
+	// message (i.e. the IV, zero), followed by the rest of the original message:
+	//
+	// prefix = pad(P')
+	// state = CBC-MAC state after prefix
+	// forged = prefix || (O[0] ^ state) || O[1:]
+	//
+	// The prefix is a full number of blocks so the padding of the original message stays the same.
+	//
+	// P' ends with a comment so that the rest is ignored, but the glue block is random and might
+	// contain a line break, which would end the comment. In that case we add a space to P' and try again.
+	
+	code := []byte("alert('Ayo, the Wu is back!');")
+	var forged []byte
+	for {
+		prefix := cryptoutil.AppendBytes([]byte{}, code)
+		prefix = cryptoutil.AppendBytes(prefix, []byte("//"))
+		prefix = cryptoutil.Pkcs7padding(prefix, len(prefix) + cryptoutil.Pkcs7paddingCount(prefix))
+		
+		// The state after the prefix is the last block of its CBC encryption. hash() would pad
+		// the prefix again, so encrypt it directly.
+		encrypted := cryptoutil.AES128CBCEncrypt(prefix, []byte("YELLOW SUBMARINE"), make([]byte, 16))
+		state := encrypted[len(encrypted) - bs:]
+		glue := cryptoutil.RepeatingKeyXor(original[0:bs], state)
+		
+		if !bytes.ContainsAny(glue, "\n\r") {
+			forged = cryptoutil.AppendBytes(prefix, glue)
+			forged = cryptoutil.AppendBytes(forged, original[bs:])
+			break
+		}
+		code = append(code, ' ')
+	}
+	
+	log.Printf("Forged hash: %x", hash(forged))
+	log.Println("Same hash:", cryptoutil.SliceEquals(hash(forged), target))
+	log.Printf("Forged message: %q", forged)
+}
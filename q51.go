@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"./cryptoutil"
+)
+
+const SESSION_ID = "TmV2ZXIgcmV2ZWFsIHRoZSBXdS1UYW5nIFNlY3JldCE="
+const ALPHABET = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/=\n"
+
+// Number of times the guess is repeated in the body. A single guess is too short for the
+// compressor to bother, and it just stores the request.
+const REPEAT = 3
+
+// Number of boundaries at which a character has to be the only one that fits
+const VOTES = 2
+
+func formatRequest(body []byte) []byte {
+	return []byte(fmt.Sprintf("POST / HTTP/1.1\nHost: hapless.com\nCookie: sessionid=%s\nContent-Length: %d\n%s", SESSION_ID, len(body), body))
+}
+
+func compress(data []byte) []byte {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write(data)
+	w.Close()
+	return b.Bytes()
+}
+
+// The oracle compresses the request, encrypts it with a new key every time, and only
+// tells us the length of the result.
+func ctrOracle(body []byte) int {
+	key := cryptoutil.RandomBytes(16)
+	nonce := int64(binary.LittleEndian.Uint64(cryptoutil.RandomBytes(8)))
+	return len(cryptoutil.AES128CTREncrypt(compress(formatRequest(body)), key, nonce))
+}
+
+func cbcOracle(body []byte) int {
+	plaintext := compress(formatRequest(body))
+	plaintext = cryptoutil.Pkcs7padding(plaintext, len(plaintext) + cryptoutil.Pkcs7paddingCount(plaintext))
+	return len(cryptoutil.AES128CBCEncrypt(plaintext, cryptoutil.RandomBytes(16), cryptoutil.RandomBytes(16)))
+}
+
+// Maximum length of the junk: there are only 112 bytes >= 144, and past that the junk would
+// repeat itself and get compressed.
+const MAX_JUNK = 112
+
+// Returns n bytes of junk (n <= MAX_JUNK), which can't appear in the session id and are all
+// different, so that they can't be compressed. They are all >= 144: with the fixed Huffman codes
+// of DEFLATE these take 9 bits each rather than 8, so adding one byte of junk shifts the bit
+// alignment of everything after it (ASCII would just add exactly one byte to the output).
+func junk(n int) []byte {
+	var output []byte
+	for i := 0; i < n; i++ {
+		output = append(output, byte(144 + (i * 37) % 112))
+	}
+	return output
+}
+
+// Returns the body of the request for a guess of the character after `known`, preceded by
+// n bytes of junk.
+func makeBody(known []byte, c byte, n int) []byte {
+	guess := append(cryptoutil.AppendBytes([]byte{}, known), c)
+	body := junk(n)
+	for i := 0; i < REPEAT; i++ {
+		body = cryptoutil.AppendBytes(body, guess)
+	}
+	return body
+}
+
+// Finds the character that comes after `known` in the request. If the guess is right, the
+// compressor can reference the whole "sessionid=..." string from the cookie, so the output is
+// a few bits shorter than with a wrong guess.
+//
+// With CTR the length of the ciphertext is the length of the compressed data, so a difference
+// of a few bits is usually lost in the rounding to bytes. With CBC it's even worse, since the
+// length is rounded to the block size. In both cases, we add junk before our guess, one byte at
+// a time, until the ciphertext for a guess that is surely wrong ('~' isn't in base64) gets
+// longer. The compressed data is then just past a byte or block boundary, and the right guess
+// is the only one that fits before it.
+//
+// Once in a while, the compressor finds a slightly better encoding for a wrong guess, so we
+// only accept a character once it has won at several boundaries.
+func nextChar(oracle func([]byte) int, known []byte) (byte, bool) {
+	votes := map[byte]int{}
+	previous := oracle(makeBody(known, '~', 0))
+	for n := 1; n <= MAX_JUNK; n++ {
+		length := oracle(makeBody(known, '~', n))
+		boundary := length > previous
+		previous = length
+		if !boundary { continue }
+
+		var bestChar byte
+		count := 0
+		for i := 0; i < len(ALPHABET); i++ {
+			if oracle(makeBody(known, ALPHABET[i], n)) < length {
+				bestChar = ALPHABET[i]
+				count++
+			}
+		}
+		if count == 1 {
+			votes[bestChar]++
+			if votes[bestChar] == VOTES { return bestChar, true }
+		}
+	}
+	return 0, false
+}
+
+func attack(oracle func([]byte) int) ([]byte, int) {
+	known := []byte("sessionid=")
+	queries := 0
+	counting := func(body []byte) int {
+		queries++
+		return oracle(body)
+	}
+	for {
+		c, ok := nextChar(counting, known)
+		if !ok || c == '\n' { break }
+		known = append(known, c)
+	}
+	return known[len("sessionid="):], queries
+}
+
+func main() {
+	secret, queries := attack(ctrOracle)
+	log.Printf("CTR: recovered %s with %d queries", secret, queries)
+	log.Println("Correct:", string(secret) == SESSION_ID)
+
+	secret, queries = attack(cbcOracle)
+	log.Printf("CBC: recovered %s with %d queries", secret, queries)
+	log.Println("Correct:", string(secret) == SESSION_ID)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/hex"
+	"log"
+	"runtime"
+	"time"
+	"./weakhash"
+)
+
+func main() {
+	workers := runtime.NumCPU()
+	
+	// Joux multicollisions: 2^n colliding messages for the price of n collisions
+	
+	f := weakhash.New(16)
+	collisions := weakhash.Multicollision(f, f.IV(), 5, workers)
+	log.Printf("%d collisions of a 16-bit hash, %d calls to the compression function", len(collisions), f.Calls())
+	for _, m := range weakhash.CollisionMessages(collisions)[0:4] {
+		log.Printf("%s -> %x", hex.EncodeToString(m), f.Sum(m))
+	}
+	log.Printf("... and %d more", (1 << uint(len(collisions))) - 4)
+	
+	// A cascade f || g of a cheap 16-bit hash and an expensive 32-bit hash has a 48-bit output, so
+	// a birthday attack should take about 2^24 calls. But a collision only costs a multicollision
+	// of f with 2^16 messages (16 * 2^8 calls to f), and a birthday search for g among them
+	// (about 2^17 calls to g).
+	
+	f = weakhash.New(16)
+	g := weakhash.New(32)
+	cascade := &weakhash.Cascade{F: f, G: g}
+	start := time.Now()
+	m1, m2 := weakhash.FindCascadeCollision(cascade, workers)
+	log.Println("Cascade collision found in", time.Since(start))
+	log.Printf("%s -> %x", hex.EncodeToString(m1), cascade.Sum(m1))
+	log.Printf("%s -> %x", hex.EncodeToString(m2), cascade.Sum(m2))
+	log.Printf("Calls to f: %d, calls to g: %d (a generic birthday attack would take about %d)", f.Calls(), g.Calls(), 1 << 24)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"log"
+	"runtime"
+	"time"
+	"./cryptoutil"
+	"./weakhash"
+)
+
+// Size of the hash state in bits, and log2 of the length of the message in blocks. The attack
+// takes about K * 2^(BITS / 2 + 1) + 2^(BITS - K) calls to the compression function.
+const BITS = 32
+const K = 16
+
+func main() {
+	workers := runtime.NumCPU()
+	h := weakhash.New(BITS)
+	
+	// A long message: 2^K blocks
+	
+	message := cryptoutil.RandomBytes(weakhash.BlockSize << K)
+	digest := h.Sum(message)
+	log.Printf("Message of %d blocks, hash %x", len(message) / weakhash.BlockSize, digest)
+	
+	// A second preimage should take 2^BITS calls. But the message goes through 2^K intermediate
+	// states, and reaching any of them is enough, as long as we can fix the length of what comes
+	// before, which is what the expandable message is for.
+	
+	h.ResetCalls()
+	start := time.Now()
+	preimage, ok := weakhash.SecondPreimage(h, message, K, workers)
+	if !ok {
+		log.Println("Second preimage not found")
+		return
+	}
+	calls := h.Calls()
+	log.Println("Second preimage found in", time.Since(start))
+	log.Printf("Calls to the compression function: %d (instead of about %d)", calls, uint64(1) << BITS)
+	log.Println("Different message:", !cryptoutil.SliceEquals(preimage, message))
+	log.Println("Same length:", len(preimage) == len(message))
+	log.Printf("Hash: %x", h.Sum(preimage))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+	"./cryptoutil"
+	"./weakhash"
+)
+
+// Size of the hash state in bits, and log2 of the number of leaves of the diamond structure.
+// Building the diamond takes about 2^(K + BITS / 2 + 1) calls to the compression function, and
+// herding a message about 2^(BITS - K).
+const BITS = 32
+const K = 8
+
+// Length of the prediction, in blocks
+const PREFIX_BLOCKS = 4
+
+func main() {
+	workers := runtime.NumCPU()
+	h := weakhash.New(BITS)
+	
+	// The diamond structure is saved as it is built, so the program can be interrupted and run
+	// again to resume the construction.
+	
+	checkpoint := filepath.Join(os.TempDir(), fmt.Sprintf("q54_diamond_%d_%d.json", BITS, K))
+	log.Println("Building the diamond structure, checkpoint in", checkpoint)
+	start := time.Now()
+	diamond := weakhash.BuildDiamond(h, K, workers, checkpoint)
+	log.Printf("Diamond built in %v, %d calls", time.Since(start), h.Calls())
+	
+	// Before the season, we publish the hash of our prediction of the results
+	
+	prediction := diamond.Prediction(h, PREFIX_BLOCKS)
+	log.Printf("Prediction: %x", prediction)
+	
+	// After the season, we write the actual results, and add some "garbage" that makes the hash
+	// match: a link block to one of the leaves of the diamond, then the path to the root.
+	
+	results := []byte("Yellow 3 - Submarine 1, Merkle 2 - Damgard 2, Kelsey 0 - Kohno 4")
+	prefix := cryptoutil.AppendBytes(results, cryptoutil.FillBytes(' ', PREFIX_BLOCKS * weakhash.BlockSize - len(results)))
+	h.ResetCalls()
+	start = time.Now()
+	message := diamond.Herd(h, prefix, workers)
+	log.Printf("Link block found in %v, %d calls", time.Since(start), h.Calls())
+	log.Printf("Message: %q", message)
+	log.Printf("Hash: %x", h.Sum(message))
+	log.Println("Matches the prediction:", cryptoutil.SliceEquals(h.Sum(message), prediction))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"log"
+	"math/big"
+	"./dlog"
+)
+
+func fromDec(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+// g has prime order q, and p - 1 = q * j where j has many small factors
+var p = fromDec("7199773997391911030609999317773941274322764333428698921736339643928346453700085358802973900485592910475480089726140708102474957429903531369589969318716771")
+var g = fromDec("4565356397095740655436854503483826832136106141639563487732438195343690437606117828318042418238184896212352329118608100083187535033402010599512641674644143")
+var q = fromDec("236234353446506858198510045061214171961")
+
+var x *big.Int // the server's private key
+var message = []byte("crazy flamboyant for the rap enjoyment")
+
+func mac(k *big.Int) []byte {
+	h := hmac.New(sha256.New, k.Bytes())
+	h.Write(message)
+	return h.Sum(nil)
+}
+
+// The server uses our public key h without checking it, and sends back a MAC made with the
+// shared secret h^x.
+func server(h *big.Int) []byte {
+	return mac(new(big.Int).Exp(h, x, p))
+}
+
+func main() {
+	x, _ = rand.Int(rand.Reader, q)
+	y := new(big.Int).Exp(g, x, p)
+	
+	// Every valid public key is in the subgroup of order q, so the attacker should only learn
+	// something about x mod q by solving a discrete log in that subgroup. But the server accepts
+	// elements of any order r dividing p - 1, and the MAC then tells us x mod r.
+	
+	found, product := dlog.SmallSubgroupAttack(p, q, 1 << 16, server, func(k *big.Int, tag []byte) bool {
+		return hmac.Equal(mac(k), tag)
+	})
+	log.Printf("x = %v mod %v", found, product)
+	if product.Cmp(q) <= 0 {
+		log.Println("Not enough small factors")
+		return
+	}
+	log.Println("Recovered private key:", found)
+	log.Println("Matches the public key:", new(big.Int).Exp(g, found, p).Cmp(y) == 0)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"log"
+	"math/big"
+	"runtime"
+	"time"
+	"./dlog"
+)
+
+func fromDec(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+// Same setup as q57, but (p - 1) / q has fewer small factors
+var p = fromDec("11470374874925275658116663507232161402086650258453896274534991676898999262641581519101074740642369848233294239851519212341844337347119899874391456329785623")
+var q = fromDec("335062023296420808191071248367701059461")
+var g = fromDec("622952335333961296978159266084741085889881358738459939978290179936063635566740258555167783009058567397963466103140082647486611657350811560630587013183357")
+
+var x *big.Int
+var message = []byte("crazy flamboyant for the rap enjoyment")
+
+func mac(k *big.Int) []byte {
+	h := hmac.New(sha256.New, k.Bytes())
+	h.Write(message)
+	return h.Sum(nil)
+}
+
+func server(h *big.Int) []byte {
+	return mac(new(big.Int).Exp(h, x, p))
+}
+
+func main() {
+	workers := runtime.NumCPU()
+	
+	// First, the kangaroo on its own, for a log known to be in [0, 2^20]
+	
+	secret := big.NewInt(705485)
+	found, _ := dlog.Kangaroo(g, new(big.Int).Exp(g, secret, p), p, big.NewInt(0), big.NewInt(1 << 20), workers)
+	log.Println("Log in [0, 2^20]:", found)
+	
+	// The small subgroup attack only gives us x mod r, with r much smaller than q
+	
+	x, _ = rand.Int(rand.Reader, q)
+	y := new(big.Int).Exp(g, x, p)
+	start := time.Now()
+	n, r := dlog.SmallSubgroupAttack(p, q, 1 << 16, server, func(k *big.Int, tag []byte) bool {
+		return hmac.Equal(mac(k), tag)
+	})
+	log.Printf("x = %v mod %v (%d bits)", n, r, r.BitLen())
+	
+	// But then x = n + m * r, with m <= (q - 1) / r, and:
+	//
+	// y * g^-n = g^(m * r) = (g^r)^m
+	//
+	// So m is the log of y' = y * g^-n to the base g' = g^r, in an interval of about 2^(128 - bits(r)),
+	// which the kangaroo can handle.
+	
+	gPrime := new(big.Int).Exp(g, r, p)
+	yPrime := new(big.Int).Exp(g, n, p)
+	yPrime.ModInverse(yPrime, p)
+	yPrime.Mul(yPrime, y)
+	yPrime.Mod(yPrime, p)
+	bound := new(big.Int).Sub(q, big.NewInt(1))
+	bound.Div(bound, r)
+	log.Printf("Searching for m in [0, %v] with %d workers", bound, workers)
+	
+	m, ok := dlog.Kangaroo(gPrime, yPrime, p, big.NewInt(0), bound, workers)
+	if !ok {
+		log.Println("Kangaroo failed")
+		return
+	}
+	found = new(big.Int).Add(n, m.Mul(m, r))
+	log.Println("Recovered private key:", found)
+	log.Println("Matches the public key:", new(big.Int).Exp(g, found, p).Cmp(y) == 0)
+	log.Println("Time:", time.Since(start))
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"log"
+	"math/big"
+	"./ec"
+	"./numutil"
+)
+
+var params = ec.DefaultParameters()
+var key *ec.PrivateKey
+var message = []byte("crazy flamboyant for the rap enjoyment")
+
+func mac(shared ec.Point) []byte {
+	h := hmac.New(sha256.New, ec.DeriveKey(shared.Bytes(params.Curve.ByteSize())))
+	h.Write(message)
+	return h.Sum(nil)
+}
+
+// ECDH with the server: we send our public key, it computes the shared secret and sends back
+// a message authenticated with it. The server doesn't check that our point is on the curve,
+// unless validate is true.
+func handshake(peer ec.Point, validate bool) ([]byte, bool) {
+	if validate && !ec.ValidatePublicKey(params, peer) { return nil, false }
+	return mac(ec.SharedSecret(key, peer)), true
+}
+
+// Returns a point of order r, for r a prime factor of the order of the curve. A random point
+// times order / r^e (with r^e the largest power of r dividing the order) has an order that is a
+// power of r, and we multiply it by r until the next multiplication would give the identity.
+func pointOfOrder(curve *ec.Curve, order *big.Int, r *big.Int) ec.Point {
+	cofactor := new(big.Int).Set(order)
+	for new(big.Int).Mod(cofactor, r).Sign() == 0 {
+		cofactor.Div(cofactor, r)
+	}
+	for {
+		h := curve.ScalarMult(curve.RandomPoint(), cofactor)
+		if h.IsInfinity() { continue }
+		for {
+			next := curve.ScalarMult(h, r)
+			if next.IsInfinity() { return h }
+			h = next
+		}
+	}
+}
+
+type invalidCurve struct {
+	b int64
+	order string
+}
+
+func main() {
+	key = ec.GenerateKey(params)
+	
+	// Normal exchange
+	
+	client := ec.GenerateKey(params)
+	tag, _ := handshake(client.Y, false)
+	log.Println("Legitimate handshake, MAC valid:", hmac.Equal(tag, mac(ec.SharedSecret(client, key.Y))))
+	
+	// The formulas for point addition don't use b, so if we send a point on a curve with the
+	// same a and a different b, the server computes x * P on that curve. We pick curves whose
+	// order has small factors r, and send a point of order r. The result can only be one of r
+	// points, and we try them all until we find the one that gives the same MAC: the index is
+	// x mod r.
+	
+	curves := []invalidCurve{
+		{210, "233970423115425145550826547352470124412"},
+		{504, "233970423115425145544350131142039591210"},
+		{727, "233970423115425145545378039958152057148"},
+	}
+	
+	var residues []*big.Int
+	var moduli []*big.Int
+	product := big.NewInt(1)
+	used := make(map[int64]bool)
+	for _, c := range curves {
+		curve := &ec.Curve{P: params.Curve.P, A: params.Curve.A, B: big.NewInt(c.b)}
+		order, _ := new(big.Int).SetString(c.order, 10)
+		if !curve.ScalarMult(curve.RandomPoint(), order).IsInfinity() {
+			log.Fatalf("Wrong order for b = %d", c.b)
+		}
+		
+		for _, r := range numutil.SmallFactors(order, 1 << 16) {
+			// Factors already used on another curve don't give any new information
+			if used[r.Int64()] || product.Cmp(params.N) > 0 { continue }
+			
+			h := pointOfOrder(curve, order, r)
+			
+			tag, ok := handshake(h, false)
+			if !ok { continue }
+			if _, ok := handshake(h, true); ok {
+				log.Fatal("The validating server accepted an invalid point")
+			}
+			
+			pt := ec.Infinity()
+			for k := int64(0); k < r.Int64(); k++ {
+				if hmac.Equal(tag, mac(pt)) {
+					log.Printf("b = %d: x = %d mod %v", c.b, k, r)
+					residues = append(residues, big.NewInt(k))
+					moduli = append(moduli, r)
+					product.Mul(product, r)
+					used[r.Int64()] = true
+					break
+				}
+				pt = curve.Add(pt, h)
+			}
+		}
+	}
+	
+	if product.Cmp(params.N) <= 0 {
+		log.Println("Not enough residues, the product of the moduli is", product)
+		return
+	}
+	
+	// The product of the moduli is larger than the order of the base point, so the CRT gives
+	// us the whole private key.
+	
+	x := numutil.CRT(residues, moduli)
+	log.Println("Recovered private key:", x)
+	log.Println("Matches the real key:", x.Cmp(key.X) == 0)
+}
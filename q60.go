@@ -0,0 +1,220 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"log"
+	"math/big"
+	"time"
+	"./ec"
+	"./numutil"
+)
+
+var params = ec.DefaultMontgomeryParameters()
+var curve = params.Curve
+var key *ec.MontgomeryPrivateKey
+var message = []byte("crazy flamboyant for the rap enjoyment")
+
+func mac(shared *big.Int) []byte {
+	size := (curve.P.BitLen() + 7) / 8
+	h := hmac.New(sha256.New, ec.DeriveKey(shared.FillBytes(make([]byte, size))))
+	h.Write(message)
+	return h.Sum(nil)
+}
+
+// The server only uses the ladder, and doesn't check that our u is on the curve
+func handshake(u *big.Int) []byte {
+	return mac(ec.MontgomerySharedSecret(key, u))
+}
+
+// u^3 + A * u^2 + u
+func rhs(u *big.Int) *big.Int {
+	output := new(big.Int).Exp(u, big.NewInt(3), curve.P)
+	output.Add(output, new(big.Int).Mul(curve.A, new(big.Int).Mul(u, u)))
+	output.Add(output, u)
+	return output.Mod(output, curve.P)
+}
+
+func randomTwistPoint() *big.Int {
+	for {
+		u, _ := rand.Int(rand.Reader, curve.P)
+		if u.Sign() != 0 && !curve.IsOnCurve(u) { return u }
+	}
+}
+
+// Returns a point of the twist whose order is the product of the given primes, which must each
+// divide the order of the twist exactly once.
+func twistPointOfOrder(twistOrder *big.Int, primes ...*big.Int) *big.Int {
+	cofactor := new(big.Int).Set(twistOrder)
+	for _, r := range primes {
+		cofactor.Div(cofactor, r)
+	}
+	for {
+		h := curve.Ladder(randomTwistPoint(), cofactor)
+		ok := h.Sign() != 0
+		for _, r := range primes {
+			// h * (order / r) must not be the identity for any r
+			ok = ok && curve.Ladder(h, new(big.Int).Div(productOf(primes), r)).Sign() != 0
+		}
+		if ok { return h }
+	}
+}
+
+func productOf(values []*big.Int) *big.Int {
+	output := big.NewInt(1)
+	for _, v := range values {
+		output.Mul(output, v)
+	}
+	return output
+}
+
+// Finds k in [0, r / 2] such that the server's MAC matches u(k * h), for h of order r on the twist.
+//
+// Computing each multiple with the ladder would be slow. Instead we move to a Weierstrass
+// curve: with d = u^3 + A * u^2 + u (not a square), the twist is d * y^2 = x^3 + a * x + b, which
+// is isomorphic to Y^2 = X^3 + a * d^2 * X + b * d^3 with X = d * x and Y = d^2 * y. Then (u, 1)
+// on the twist maps to a point we can add repeatedly.
+func bruteForce(h *big.Int, r *big.Int, tag []byte) (int64, bool) {
+	p := curve.P
+	w := curve.Weierstrass()
+	d := rhs(h)
+	d2 := new(big.Int).Mul(d, d)
+	twist := &ec.Curve{P: p, A: new(big.Int).Mod(new(big.Int).Mul(w.A, d2), p), B: new(big.Int).Mod(new(big.Int).Mul(w.B, new(big.Int).Mul(d2, d)), p)}
+	x := new(big.Int).Mul(curve.ToWeierstrassX(h), d)
+	start := ec.Point{X: x.Mod(x, p), Y: d2.Mod(d2, p)}
+	dInverse := new(big.Int).ModInverse(d, p)
+
+	pt := ec.Infinity()
+	for k := int64(0); k <= r.Int64() / 2; k++ {
+		u := big.NewInt(0)
+		if !pt.IsInfinity() {
+			u = curve.FromWeierstrassX(new(big.Int).Mul(pt.X, dInverse))
+		}
+		if hmac.Equal(tag, mac(u)) { return k, true }
+		pt = twist.Add(pt, start)
+	}
+	return 0, false
+}
+
+// Baby-step giant-step: finds m in [0, bound) such that m * h = y on the Weierstrass curve, for
+// one of the targets y. Returns the index of the target and m. The table of baby steps is
+// shared by all the targets.
+func babyStepGiantStep(w *ec.Curve, h ec.Point, targets []ec.Point, bound *big.Int) (int, *big.Int, bool) {
+	m := new(big.Int).Sqrt(bound).Int64() + 1
+	key := func(pt ec.Point) uint64 {
+		if pt.IsInfinity() { return 0 }
+		return pt.X.Uint64()
+	}
+	baby := make(map[uint64]int64)
+	pt := ec.Infinity()
+	for j := int64(0); j < m; j++ {
+		baby[key(pt)] = j
+		pt = w.Add(pt, h)
+	}
+	// pt = m * h
+	step := w.Neg(pt)
+	for t, y := range targets {
+		z := y
+		for i := int64(0); i <= m; i++ {
+			// The table only has the low bits of x, which match both j * h and -j * h, and the
+			// occasional false positive
+			if j, found := baby[key(z)]; found {
+				for _, candidate := range []int64{i * m + j, i * m - j} {
+					if candidate < 0 { continue }
+					result := big.NewInt(candidate)
+					if w.ScalarMult(h, result).Equal(y) { return t, result, true }
+				}
+			}
+			z = w.Add(z, step)
+		}
+	}
+	return 0, nil, false
+}
+
+func main() {
+	key = ec.GenerateMontgomeryKey(params)
+	start := time.Now()
+	
+	// The curve has 8 * N points, and the twist has 2 * p + 2 - 8 * N, which has several small
+	// factors. Sending a u on the twist with small order r works like the invalid curve attack,
+	// except that the u coordinate alone can't distinguish k * h from -k * h, so we only learn
+	// x mod r up to the sign. We skip the factor 2 since the point of order 2 has u = 0, which the
+	// ladder also returns for the identity.
+	
+	twistOrder := new(big.Int).Lsh(curve.P, 1)
+	twistOrder.Add(twistOrder, big.NewInt(2))
+	twistOrder.Sub(twistOrder, new(big.Int).Mul(params.N, big.NewInt(8)))
+	factors := numutil.SmallFactors(twistOrder, 1 << 24)
+	log.Println("Small factors of the twist order:", factors)
+	
+	var residues []*big.Int
+	var moduli []*big.Int
+	for _, r := range factors {
+		if r.Int64() == 2 { continue }
+		h := twistPointOfOrder(twistOrder, r)
+		k, ok := bruteForce(h, r, handshake(h))
+		if !ok { log.Fatal("No match for r = ", r) }
+		log.Printf("x = +/- %d mod %v", k, r)
+		residues = append(residues, big.NewInt(k))
+		moduli = append(moduli, r)
+	}
+	
+	// To combine the residues, we need consistent signs. We take a pivot r_0 with k_0 != 0, and
+	// for each other r_i, we query the server with a point of order r_0 * r_i: only one of
+	// CRT(k_0, k_i) and CRT(k_0, -k_i) gives the right MAC (the other combinations are their
+	// negatives).
+	
+	pivot := 0
+	for residues[pivot].Sign() == 0 {
+		pivot++
+	}
+	for i := range residues {
+		if i == pivot || residues[i].Sign() == 0 { continue }
+		pair := []*big.Int{moduli[pivot], moduli[i]}
+		h := twistPointOfOrder(twistOrder, pair...)
+		tag := handshake(h)
+		c := numutil.CRT([]*big.Int{residues[pivot], residues[i]}, pair)
+		if !hmac.Equal(tag, mac(curve.Ladder(h, c))) {
+			residues[i].Sub(moduli[i], residues[i])
+		}
+	}
+	c := numutil.CRT(residues, moduli)
+	r := productOf(moduli)
+	log.Printf("x = +/- %v mod %v (%d bits)", c, r, r.BitLen())
+	
+	// The rest of x is in a much smaller range: x = +/- c + m * r with m < N / r. We move to the
+	// Weierstrass form of the curve, where the server's public key is Y = x * G or -Y = x * G
+	// (we can't tell from u), and look for m such that +/- Y - s * G = m * (r * G), with s = c or
+	// s = r - c.
+	
+	w := curve.Weierstrass()
+	g, _ := w.Lift(curve.ToWeierstrassX(params.U))
+	y, _ := w.Lift(curve.ToWeierstrassX(key.Y))
+	bound := new(big.Int).Div(params.N, r)
+	bound.Add(bound, big.NewInt(1))
+	log.Println("Searching for m below", bound)
+	
+	var targets []ec.Point
+	var offsets []*big.Int
+	for _, s := range []*big.Int{c, new(big.Int).Sub(r, c)} {
+		sg := w.Neg(w.ScalarMult(g, s))
+		targets = append(targets, w.Add(y, sg), w.Add(w.Neg(y), sg))
+		offsets = append(offsets, s, s)
+	}
+	t, m, ok := babyStepGiantStep(w, w.ScalarMult(g, r), targets, bound)
+	if ok {
+		x := new(big.Int).Add(offsets[t], m.Mul(m, r))
+		// x and N - x give the same u, so the sign can't be resolved from the public key alone.
+		// Either one works as the private key for the Montgomery ladder though.
+		candidates := []*big.Int{x, new(big.Int).Sub(params.N, x)}
+		for _, candidate := range candidates {
+			log.Println("Candidate private key:", candidate)
+			log.Println("Public key matches:", curve.Ladder(params.U, candidate).Cmp(key.Y) == 0)
+		}
+		log.Println("One of them is the real key:", candidates[0].Cmp(key.X) == 0 || candidates[1].Cmp(key.X) == 0)
+		log.Println("Time:", time.Since(start))
+		return
+	}
+	log.Println("Key not found")
+}
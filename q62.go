@@ -0,0 +1,50 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+	"./dsa"
+	"./lattice"
+)
+
+// A signer with a broken nonce generator: the low byte of each nonce is always 0
+func biasedSignature(key *dsa.PrivateKey, message []byte, zeroBits int) *dsa.Signature {
+	h := dsa.HashMessage(message)
+	for {
+		k, _ := rand.Int(rand.Reader, new(big.Int).Rsh(key.Q, uint(zeroBits)))
+		k.Lsh(k, uint(zeroBits))
+		signature, ok := dsa.SignWithNonce(key, h, k)
+		if ok { return signature }
+	}
+}
+
+func main() {
+	params := dsa.DefaultParameters()
+	key := dsa.GenerateKey(params)
+	
+	// Each signature leaks 8 bits of information about the private key (see lattice/hnp.go), and
+	// q has 160 bits, so in theory we need 20 signatures. In practice LLL doesn't always find the
+	// shortest vector, so we keep collecting signatures until the attack works.
+	
+	zeroBits := 8
+	var samples []lattice.Sample
+	for i := 0; ; i++ {
+		message := []byte(fmt.Sprintf("Signed message #%d", i))
+		signature := biasedSignature(key, message, zeroBits)
+		samples = append(samples, lattice.Sample{H: dsa.HashMessage(message), R: signature.R, S: signature.S})
+		if len(samples) < 20 { continue }
+		
+		start := time.Now()
+		x, ok := lattice.RecoverBiasedNonceKey(params.Q, samples, zeroBits)
+		ok = ok && dsa.IsPrivateKey(&key.PublicKey, x)
+		log.Printf("%d signatures: success %v (%v)", len(samples), ok, time.Since(start))
+		if ok {
+			log.Println("Private key:", x)
+			log.Println("Matches the real key:", x.Cmp(key.X) == 0)
+			return
+		}
+	}
+}
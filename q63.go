@@ -0,0 +1,80 @@
+package main
+
+import (
+	"log"
+	"./cryptoutil"
+	"./gf128"
+)
+
+var randomKey []byte
+var gcm *cryptoutil.GCM
+
+// The server checks messages with this. The attacker doesn't know the key.
+func verify(nonce []byte, sealed []byte, additionalData []byte) ([]byte, bool) {
+	return gcm.Open(nonce, sealed, additionalData)
+}
+
+// Builds the polynomial whose value at H is the GHASH of the message plus its tag:
+//
+// tag + B1 * H^n + B2 * H^(n-1) + ... + Bn * H
+//
+// Since tag = GHASH(H) + E(K, J0), this is equal to E(K, J0) at the correct H.
+func ghashPolynomial(additionalData []byte, ciphertext []byte, tag []byte) gf128.Polynomial {
+	blocks := cryptoutil.GHASHBlocks(additionalData, ciphertext)
+	output := make(gf128.Polynomial, len(blocks) + 1)
+	output[0] = gf128.NewElement(tag)
+	for i, block := range blocks {
+		output[len(blocks) - i] = block
+	}
+	return output
+}
+
+func main() {
+	randomKey = cryptoutil.RandomBytes(16)
+	gcm = cryptoutil.NewGCM(randomKey)
+	
+	// The server reuses the same nonce for two messages
+	
+	nonce := cryptoutil.RandomBytes(12)
+	additionalData := []byte("from=alice")
+	plaintext1 := []byte("to=bob;amount=100;comment=rent for march")
+	plaintext2 := []byte("to=carol;amount=20;comment=lunch")
+	sealed1 := gcm.Seal(nonce, plaintext1, additionalData)
+	sealed2 := gcm.Seal(nonce, plaintext2, additionalData)
+	
+	// For both messages, E(K, J0) is the same since it only depends on the key and nonce. So if we
+	// add the two polynomials, it cancels out and H is a root of the result:
+	//
+	// f(H) = tag1 + GHASH1(H) + tag2 + GHASH2(H) = 0
+	
+	c1, tag1 := sealed1[0:len(sealed1) - 16], sealed1[len(sealed1) - 16:]
+	c2, tag2 := sealed2[0:len(sealed2) - 16], sealed2[len(sealed2) - 16:]
+	p1 := ghashPolynomial(additionalData, c1, tag1)
+	p2 := ghashPolynomial(additionalData, c2, tag2)
+	f := p1.Add(p2)
+	candidates := f.Roots()
+	log.Printf("Polynomial of degree %d has %d root(s)", f.Degree(), len(candidates))
+	
+	// There might be several candidates. For each of them, we forge a message and submit it
+	// to the server to find the right one. The forged message changes the amount using a
+	// CTR bit flip, then computes the tag as:
+	//
+	// tag = E(K, J0) + GHASH(H), with E(K, J0) = p1(H)
+	
+	forgedCiphertext, _ := cryptoutil.CTRBitFlip(c1, 14, []byte("100"), []byte("999"))
+	forged := false
+	for _, h := range candidates {
+		s := p1.Eval(h)
+		tag := cryptoutil.GHASH(h, additionalData, forgedCiphertext).Add(s)
+		plaintext, ok := verify(nonce, cryptoutil.AppendBytes(cryptoutil.AppendBytes([]byte{}, forgedCiphertext), tag.Bytes()), additionalData)
+		if ok {
+			log.Println("Authentication key H:", h)
+			log.Println("Forged message accepted:", string(plaintext))
+			forged = true
+			break
+		}
+	}
+	if !forged {
+		log.Println("Could not forge a message")
+	}
+}
@@ -0,0 +1,180 @@
+package rsa
+
+import (
+	"crypto/rand"
+	"math/big"
+	"../numutil"
+)
+
+// Textbook RSA (no padding), for experimenting with attacks.
+
+var zero = big.NewInt(0)
+var one = big.NewInt(1)
+var two = big.NewInt(2)
+
+type PublicKey struct {
+	N *big.Int
+	E *big.Int
+}
+
+type PrivateKey struct {
+	PublicKey
+	D *big.Int
+	// Values used to speed up decryption with the Chinese remainder theorem
+	P *big.Int
+	Q *big.Int
+	Dp *big.Int // d mod (p - 1)
+	Dq *big.Int // d mod (q - 1)
+	Qinv *big.Int // q^-1 mod p
+}
+
+var smallPrimes = []int64{3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53, 59, 61, 67, 71, 73, 79, 83, 89, 97}
+
+// Miller-Rabin primality test with the given number of random bases
+func IsProbablePrime(n *big.Int, rounds int) bool {
+	if n.Cmp(two) < 0 { return false }
+	for _, p := range smallPrimes {
+		bp := big.NewInt(p)
+		if n.Cmp(bp) == 0 { return true }
+		if new(big.Int).Mod(n, bp).Sign() == 0 { return false }
+	}
+	if n.Bit(0) == 0 { return n.Cmp(two) == 0 }
+
+	// n - 1 = d * 2^s
+	nMinusOne := new(big.Int).Sub(n, one)
+	d := new(big.Int).Set(nMinusOne)
+	s := 0
+	for d.Bit(0) == 0 {
+		d.Rsh(d, 1)
+		s++
+	}
+
+	max := new(big.Int).Sub(n, big.NewInt(3))
+	for i := 0; i < rounds; i++ {
+		a, _ := rand.Int(rand.Reader, max)
+		a.Add(a, two) // a in [2, n - 2]
+		x := new(big.Int).Exp(a, d, n)
+		if x.Cmp(one) == 0 || x.Cmp(nMinusOne) == 0 { continue }
+		composite := true
+		for j := 1; j < s; j++ {
+			x.Exp(x, two, n)
+			if x.Cmp(nMinusOne) == 0 {
+				composite = false
+				break
+			}
+		}
+		if composite { return false }
+	}
+	return true
+}
+
+// Returns a random prime of exactly the given number of bits
+func GeneratePrime(bits int) *big.Int {
+	for {
+		p, _ := rand.Int(rand.Reader, new(big.Int).Lsh(one, uint(bits)))
+		// Set the top two bits so that the product of two primes has exactly 2 * bits bits,
+		// and the bottom one so that it's odd.
+		p.SetBit(p, bits - 1, 1)
+		p.SetBit(p, bits - 2, 1)
+		p.SetBit(p, 0, 1)
+		if IsProbablePrime(p, 40) {
+			return p
+		}
+	}
+}
+
+// Extended Euclidean algorithm. Returns gcd(a, b), x and y such that a * x + b * y = gcd(a, b)
+func ExtendedGcd(a *big.Int, b *big.Int) (*big.Int, *big.Int, *big.Int) {
+	return numutil.ExtendedGcd(a, b)
+}
+
+// Returns a^-1 mod m, or `false` if a and m are not coprime
+func InvMod(a *big.Int, m *big.Int) (*big.Int, bool) {
+	return numutil.InvMod(a, m)
+}
+
+// Generates a key with a modulus of the given number of bits and the given public exponent.
+func GenerateKey(bits int, e int64) *PrivateKey {
+	bigE := big.NewInt(e)
+	for {
+		p := GeneratePrime(bits / 2)
+		q := GeneratePrime(bits - bits / 2)
+		if p.Cmp(q) == 0 { continue }
+
+		// e must be invertible modulo phi = (p - 1)(q - 1), otherwise try other primes
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d, ok := InvMod(bigE, phi)
+		if !ok { continue }
+
+		output := new(PrivateKey)
+		output.N = new(big.Int).Mul(p, q)
+		output.E = bigE
+		output.D = d
+		output.P = p
+		output.Q = q
+		output.Dp = new(big.Int).Mod(d, new(big.Int).Sub(p, one))
+		output.Dq = new(big.Int).Mod(d, new(big.Int).Sub(q, one))
+		output.Qinv, _ = InvMod(q, p)
+		return output
+	}
+}
+
+// c = m^e mod n
+func Encrypt(key *PublicKey, m *big.Int) *big.Int {
+	return new(big.Int).Exp(m, key.E, key.N)
+}
+
+// m = c^d mod n, computed with the Chinese remainder theorem (Garner's formula):
+//
+// m1 = c^dp mod p
+// m2 = c^dq mod q
+// m = m2 + q * (qinv * (m1 - m2) mod p)
+func Decrypt(key *PrivateKey, c *big.Int) *big.Int {
+	if key.P == nil {
+		return new(big.Int).Exp(c, key.D, key.N)
+	}
+	m1 := new(big.Int).Exp(c, key.Dp, key.P)
+	m2 := new(big.Int).Exp(c, key.Dq, key.Q)
+	h := new(big.Int).Sub(m1, m2)
+	h.Mul(h, key.Qinv)
+	h.Mod(h, key.P)
+	return h.Mul(h, key.Q).Add(h, m2)
+}
+
+func EncryptBytes(key *PublicKey, message []byte) []byte {
+	return Encrypt(key, new(big.Int).SetBytes(message)).Bytes()
+}
+
+func DecryptBytes(key *PrivateKey, ciphertext []byte) []byte {
+	return Decrypt(key, new(big.Int).SetBytes(ciphertext)).Bytes()
+}
+
+// Returns the integer n-th root of x (rounded down), and whether it is exact, using Newton's method.
+func Root(x *big.Int, n int) (*big.Int, bool) {
+	if x.Sign() <= 0 { return new(big.Int), x.Sign() == 0 }
+	bigN := big.NewInt(int64(n))
+	nMinusOne := big.NewInt(int64(n - 1))
+
+	// Start from a power of two that is larger than the root
+	r := new(big.Int).Lsh(one, uint(x.BitLen() / n + 1))
+	for {
+		// r' = ((n - 1) * r + x / r^(n-1)) / n
+		next := new(big.Int).Exp(r, nMinusOne, nil)
+		next.Div(x, next)
+		next.Add(next, new(big.Int).Mul(nMinusOne, r))
+		next.Div(next, bigN)
+		if next.Cmp(r) >= 0 { break }
+		r = next
+	}
+	return r, new(big.Int).Exp(r, bigN, nil).Cmp(x) == 0
+}
+
+func CubeRoot(x *big.Int) (*big.Int, bool) {
+	return Root(x, 3)
+}
+
+// Chinese remainder theorem. Returns x such that x = residues[i] mod moduli[i] for all i,
+// with 0 <= x < product of the moduli, which must be pairwise coprime.
+func CRT(residues []*big.Int, moduli []*big.Int) *big.Int {
+	return numutil.CRT(residues, moduli)
+}
@@ -0,0 +1,146 @@
+package rsa
+
+import (
+	"bytes"
+	"crypto"
+	stdrsa "crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+	"../cryptoutil"
+)
+
+func TestInvMod(t *testing.T) {
+	d, ok := InvMod(big.NewInt(17), big.NewInt(3120))
+	if !ok || d.Int64() != 2753 {
+		t.Errorf("expected 2753, got %v", d)
+	}
+	if _, ok := InvMod(big.NewInt(6), big.NewInt(9)); ok {
+		t.Errorf("6 should not be invertible mod 9")
+	}
+}
+
+func TestPrime(t *testing.T) {
+	if IsProbablePrime(big.NewInt(561), 20) { // Carmichael number
+		t.Errorf("561 is not prime")
+	}
+	p := GeneratePrime(256)
+	if p.BitLen() != 256 || !p.ProbablyPrime(20) {
+		t.Errorf("%v is not a 256-bit prime", p)
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	for _, e := range []int64{3, 65537} {
+		key := GenerateKey(1024, e)
+		if key.N.BitLen() != 1024 {
+			t.Errorf("unexpected modulus size: %d", key.N.BitLen())
+		}
+		message := []byte("YELLOW SUBMARINE")
+		c := EncryptBytes(&key.PublicKey, message)
+		if string(DecryptBytes(key, c)) != string(message) {
+			t.Errorf("e = %d: could not decrypt message", e)
+		}
+		// CRT decryption gives the same result as m = c^d mod n
+		bigC := new(big.Int).SetBytes(c)
+		if Decrypt(key, bigC).Cmp(new(big.Int).Exp(bigC, key.D, key.N)) != 0 {
+			t.Errorf("e = %d: CRT decryption is wrong", e)
+		}
+	}
+}
+
+func TestRoot(t *testing.T) {
+	x, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	cube := new(big.Int).Exp(x, big.NewInt(3), nil)
+	if r, exact := CubeRoot(cube); !exact || r.Cmp(x) != 0 {
+		t.Errorf("expected %v, got %v", x, r)
+	}
+	if r, exact := CubeRoot(cube.Add(cube, big.NewInt(1))); exact || r.Cmp(x) != 0 {
+		t.Errorf("expected inexact %v, got %v", x, r)
+	}
+	if r, _ := Root(big.NewInt(1), 3); r.Int64() != 1 {
+		t.Errorf("expected 1, got %v", r)
+	}
+}
+
+func TestCRT(t *testing.T) {
+	x := CRT([]*big.Int{big.NewInt(2), big.NewInt(3), big.NewInt(2)}, []*big.Int{big.NewInt(3), big.NewInt(5), big.NewInt(7)})
+	if x.Int64() != 23 {
+		t.Errorf("expected 23, got %v", x)
+	}
+}
+
+func TestPkcs1v15Signature(t *testing.T) {
+	key := GenerateKey(1024, 3)
+	digest := sha1.Sum([]byte("hi mom"))
+	signature, ok := SignPkcs1v15(key, cryptoutil.DigestInfoSHA1, digest[:])
+	if !ok {
+		t.Fatalf("could not sign")
+	}
+
+	// Same output as the standard library
+	stdKey := &stdrsa.PrivateKey{PublicKey: stdrsa.PublicKey{N: key.N, E: int(key.E.Int64())}, D: key.D, Primes: []*big.Int{key.P, key.Q}}
+	expected, err := stdrsa.SignPKCS1v15(nil, stdKey, crypto.SHA1, digest[:])
+	if err != nil || !bytes.Equal(signature, expected) {
+		t.Errorf("signature differs from crypto/rsa")
+	}
+
+	if !VerifyPkcs1v15(&key.PublicKey, cryptoutil.DigestInfoSHA1, digest[:], signature) {
+		t.Errorf("strict verifier rejected a valid signature")
+	}
+	if !VerifyPkcs1v15Sloppy(&key.PublicKey, cryptoutil.DigestInfoSHA1, digest[:], signature) {
+		t.Errorf("sloppy verifier rejected a valid signature")
+	}
+	other := sha1.Sum([]byte("hi dad"))
+	if VerifyPkcs1v15(&key.PublicKey, cryptoutil.DigestInfoSHA1, other[:], signature) ||
+		VerifyPkcs1v15Sloppy(&key.PublicKey, cryptoutil.DigestInfoSHA1, other[:], signature) {
+		t.Errorf("signature accepted for the wrong message")
+	}
+}
+
+func TestForgePkcs1v15Signature(t *testing.T) {
+	cases := []struct {
+		bits int
+		digestInfo []byte
+		digest []byte
+	}{
+		{1024, cryptoutil.DigestInfoSHA1, sha1Digest("hi mom")},
+		{2048, cryptoutil.DigestInfoSHA256, sha256Digest("hi mom")},
+	}
+	for _, c := range cases {
+		key := GenerateKey(c.bits, 3)
+		forged, ok := ForgePkcs1v15Signature(&key.PublicKey, c.digestInfo, c.digest)
+		if !ok {
+			t.Fatalf("%d bits: could not forge", c.bits)
+		}
+		if !VerifyPkcs1v15Sloppy(&key.PublicKey, c.digestInfo, c.digest, forged) {
+			t.Errorf("%d bits: sloppy verifier rejected the forgery", c.bits)
+		}
+		if VerifyPkcs1v15(&key.PublicKey, c.digestInfo, c.digest, forged) {
+			t.Errorf("%d bits: strict verifier accepted the forgery", c.bits)
+		}
+	}
+
+	// Not enough room for the garbage with SHA-256 and a 1024-bit key
+	key := GenerateKey(1024, 3)
+	if _, ok := ForgePkcs1v15Signature(&key.PublicKey, cryptoutil.DigestInfoSHA256, sha256Digest("hi mom")); ok {
+		t.Errorf("forgery should not be possible")
+	}
+
+	// The cube root only works for e = 3
+	key = GenerateKey(1024, 65537)
+	if _, ok := ForgePkcs1v15Signature(&key.PublicKey, cryptoutil.DigestInfoSHA1, sha1Digest("hi mom")); ok {
+		t.Errorf("forgery should not be possible with e = 65537")
+	}
+}
+
+func sha1Digest(s string) []byte {
+	digest := sha1.Sum([]byte(s))
+	return digest[:]
+}
+
+func sha256Digest(s string) []byte {
+	digest := sha256.Sum256([]byte(s))
+	return digest[:]
+}
@@ -0,0 +1,81 @@
+package rsa
+
+import (
+	"bytes"
+	"math/big"
+	"../cryptoutil"
+)
+
+// PKCS#1 v1.5 signatures. The digest is wrapped in an ASN.1 DigestInfo structure
+// (cryptoutil.DigestInfoSHA1 or cryptoutil.DigestInfoSHA256), padded, then "decrypted"
+// with the private key.
+
+func keySize(key *PublicKey) int {
+	return (key.N.BitLen() + 7) / 8
+}
+
+func SignPkcs1v15(key *PrivateKey, digestInfo []byte, digest []byte) ([]byte, bool) {
+	k := keySize(&key.PublicKey)
+	em, ok := cryptoutil.Pkcs1v15SignaturePadding(digestInfo, digest, k)
+	if !ok { return nil, false }
+	return Decrypt(key, new(big.Int).SetBytes(em)).FillBytes(make([]byte, k)), true
+}
+
+// Returns s^e mod n as a k-byte block
+func openSignature(key *PublicKey, signature []byte) ([]byte, bool) {
+	k := keySize(key)
+	s := new(big.Int).SetBytes(signature)
+	if len(signature) != k || s.Cmp(key.N) >= 0 { return nil, false }
+	return Encrypt(key, s).FillBytes(make([]byte, k)), true
+}
+
+// Strict verifier: builds the expected padded block and compares it to the whole decrypted
+// signature, so there's no room for any extra data.
+func VerifyPkcs1v15(key *PublicKey, digestInfo []byte, digest []byte, signature []byte) bool {
+	em, ok := openSignature(key, signature)
+	if !ok { return false }
+	expected, ok := cryptoutil.Pkcs1v15SignaturePadding(digestInfo, digest, keySize(key))
+	return ok && cryptoutil.SliceEquals(em, expected)
+}
+
+// Sloppy verifier, as found in many implementations: it parses the block from left to right,
+// skipping the 0xff bytes until the 0x00 separator, then checks the DigestInfo and digest, but
+// doesn't check that they are right-justified, i.e. that nothing comes after the digest.
+func VerifyPkcs1v15Sloppy(key *PublicKey, digestInfo []byte, digest []byte, signature []byte) bool {
+	em, ok := openSignature(key, signature)
+	if !ok || em[0] != 0x00 || em[1] != 0x01 { return false }
+	i := 2
+	for i < len(em) && em[i] == 0xff {
+		i++
+	}
+	if i == 2 || i >= len(em) || em[i] != 0x00 { return false }
+	t := cryptoutil.AppendBytes(cryptoutil.AppendBytes([]byte{}, digestInfo), digest)
+	return bytes.HasPrefix(em[i + 1:], t)
+}
+
+// Bleichenbacher's 2006 signature forgery for e = 3. Since the sloppy verifier ignores what
+// comes after the digest, we only need s such that s^3 starts with:
+//
+// 0x00 0x01 0xff 0x00 DigestInfo digest
+//
+// The rest is garbage. We take the cube root of this prefix followed by zeros, rounded up. As
+// long as the garbage is big enough (about 2/3 of the block), rounding up only changes the
+// garbage. s^3 is smaller than n, so the modulus isn't involved and the private key isn't needed.
+// Returns `false` if e isn't 3 or the key is too small for the forgery.
+func ForgePkcs1v15Signature(key *PublicKey, digestInfo []byte, digest []byte) ([]byte, bool) {
+	if key.E.Cmp(big.NewInt(3)) != 0 { return nil, false }
+	k := keySize(key)
+	prefix := []byte{0x00, 0x01, 0xff, 0x00}
+	prefix = cryptoutil.AppendBytes(prefix, digestInfo)
+	prefix = cryptoutil.AppendBytes(prefix, digest)
+	if len(prefix) > k { return nil, false }
+
+	lower := new(big.Int).SetBytes(prefix)
+	lower.Lsh(lower, uint(8 * (k - len(prefix))))
+	upper := new(big.Int).Add(lower, new(big.Int).Lsh(one, uint(8 * (k - len(prefix)))))
+
+	s, exact := CubeRoot(lower)
+	if !exact { s.Add(s, one) }
+	if new(big.Int).Exp(s, big.NewInt(3), nil).Cmp(upper) >= 0 { return nil, false }
+	return s.FillBytes(make([]byte, k)), true
+}
@@ -0,0 +1,107 @@
+package srp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sync"
+)
+
+// Simplified SRP, where B doesn't depend on the verifier and u is random:
+//
+// C->S: I, A = g^a mod N
+// S->C: salt, B = g^b mod N, u = 128-bit random number
+// C:    S = B^(a + u * x) mod N
+// S:    S = (A * v^u)^b mod N
+// C->S: HMAC-SHA256(K = H(S), salt)
+//
+// Since the password is only used in the exponent of the client computation, a server that
+// chooses b, B and u can check password guesses offline (see q38).
+
+type SimplifiedServer struct {
+	users map[string]*user
+	scramblers map[string]*big.Int
+	mutex sync.Mutex
+}
+
+func NewSimplifiedServer() *SimplifiedServer {
+	output := new(SimplifiedServer)
+	output.users = make(map[string]*user)
+	output.scramblers = make(map[string]*big.Int)
+	return output
+}
+
+func (this *SimplifiedServer) Register(identity string, password []byte) {
+	u := new(user)
+	u.salt = make([]byte, 16)
+	rand.Read(u.salt)
+	u.v = new(big.Int).Exp(G, PasswordHash(u.salt, password), N)
+	this.mutex.Lock()
+	this.users[identity] = u
+	this.mutex.Unlock()
+}
+
+func (this *SimplifiedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	identity := r.FormValue("I")
+	u, ok := this.users[identity]
+	if !ok {
+		http.Error(w, "Unknown user", http.StatusForbidden)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/start":
+		a, ok := new(big.Int).SetString(r.FormValue("A"), 16)
+		if !ok {
+			http.Error(w, "Invalid A", http.StatusBadRequest)
+			return
+		}
+		u.a = a
+		u.b = randomInt()
+		u.bigB = new(big.Int).Exp(G, u.b, N)
+		scrambler, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+		this.scramblers[identity] = scrambler
+		json.NewEncoder(w).Encode(startResponse{hex.EncodeToString(u.salt), u.bigB.Text(16), scrambler.Text(16)})
+
+	case "/verify":
+		if u.b == nil {
+			http.Error(w, "No login in progress", http.StatusForbidden)
+			return
+		}
+		// S = (A * v^u)^b mod N
+		s := new(big.Int).Exp(u.v, this.scramblers[identity], N)
+		s.Mul(s, u.a)
+		s.Exp(s, u.b, N)
+		u.b = nil
+		mac, _ := hex.DecodeString(r.FormValue("mac"))
+		if !hmac.Equal(mac, HMAC(Hash(s.Bytes()), u.salt)) {
+			http.Error(w, "Invalid password", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("OK"))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func SimplifiedLogin(serverURL string, identity string, password []byte) bool {
+	a := randomInt()
+	bigA := new(big.Int).Exp(G, a, N)
+	salt, bigB, u, ok := startLogin(serverURL, identity, bigA)
+	if !ok || u == nil { return false }
+
+	// S = B^(a + u * x) mod N
+	x := PasswordHash(salt, password)
+	exponent := new(big.Int).Mul(u, x)
+	exponent.Add(exponent, a)
+	s := new(big.Int).Exp(bigB, exponent, N)
+
+	return FinishLogin(serverURL, identity, HMAC(Hash(s.Bytes()), salt))
+}
@@ -0,0 +1,197 @@
+package srp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sync"
+	"../dh"
+)
+
+// Secure Remote Password (SRP-6a). The server stores a verifier v = g^x mod N, where
+// x = H(salt || password), and both sides prove that they know the same key without
+// sending the password:
+//
+// C->S: I, A = g^a mod N
+// S->C: salt, B = kv + g^b mod N
+// Both: u = H(A || B)
+// C:    S = (B - k * g^x)^(a + u * x) mod N
+// S:    S = (A * v^u)^b mod N
+// C->S: HMAC-SHA256(K = H(S), salt)
+//
+// The server is a http.Handler, so it can be run with net/http/httptest:
+//
+// POST /start  (I, A)   returns the salt and B as JSON
+// POST /verify (I, mac) returns 200 if the MAC is valid, 403 otherwise
+
+var N = dh.P
+var G = big.NewInt(2)
+
+// k = H(N || g)
+var K = hashInt(N.Bytes(), G.Bytes())
+
+func Hash(data ...[]byte) []byte {
+	h := sha256.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+func hashInt(data ...[]byte) *big.Int {
+	return new(big.Int).SetBytes(Hash(data...))
+}
+
+func HMAC(key []byte, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// x = H(salt || password)
+func PasswordHash(salt []byte, password []byte) *big.Int {
+	return hashInt(salt, password)
+}
+
+func randomInt() *big.Int {
+	output, _ := rand.Int(rand.Reader, N)
+	return output
+}
+
+type user struct {
+	salt []byte
+	v *big.Int
+	// Current login session
+	a *big.Int
+	b *big.Int
+	bigB *big.Int
+}
+
+type Server struct {
+	users map[string]*user
+	mutex sync.Mutex
+	ValidateA bool // Reject A if A mod N == 0. Without it, the server is vulnerable to the zero-key attack.
+}
+
+func NewServer() *Server {
+	output := new(Server)
+	output.users = make(map[string]*user)
+	return output
+}
+
+func (this *Server) Register(identity string, password []byte) {
+	u := new(user)
+	u.salt = make([]byte, 16)
+	rand.Read(u.salt)
+	u.v = new(big.Int).Exp(G, PasswordHash(u.salt, password), N)
+	this.mutex.Lock()
+	this.users[identity] = u
+	this.mutex.Unlock()
+}
+
+type startResponse struct {
+	Salt string
+	B string
+	U string `json:",omitempty"` // Only used by the simplified version
+}
+
+func (this *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	u, ok := this.users[r.FormValue("I")]
+	if !ok {
+		http.Error(w, "Unknown user", http.StatusForbidden)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/start":
+		a, ok := new(big.Int).SetString(r.FormValue("A"), 16)
+		if !ok || (this.ValidateA && new(big.Int).Mod(a, N).Sign() == 0) {
+			http.Error(w, "Invalid A", http.StatusBadRequest)
+			return
+		}
+		// B = kv + g^b mod N
+		u.a = a
+		u.b = randomInt()
+		u.bigB = new(big.Int).Mul(K, u.v)
+		u.bigB.Add(u.bigB, new(big.Int).Exp(G, u.b, N))
+		u.bigB.Mod(u.bigB, N)
+		json.NewEncoder(w).Encode(startResponse{hex.EncodeToString(u.salt), u.bigB.Text(16), ""})
+
+	case "/verify":
+		if u.b == nil {
+			http.Error(w, "No login in progress", http.StatusForbidden)
+			return
+		}
+		// S = (A * v^u)^b mod N
+		scrambler := hashInt(u.a.Bytes(), u.bigB.Bytes())
+		s := new(big.Int).Exp(u.v, scrambler, N)
+		s.Mul(s, u.a)
+		s.Exp(s, u.b, N)
+		u.b = nil
+		mac, _ := hex.DecodeString(r.FormValue("mac"))
+		if !hmac.Equal(mac, HMAC(Hash(s.Bytes()), u.salt)) {
+			http.Error(w, "Invalid password", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("OK"))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Sends I and A to the server. Returns the salt and B.
+func StartLogin(serverURL string, identity string, a *big.Int) ([]byte, *big.Int, bool) {
+	salt, b, _, ok := startLogin(serverURL, identity, a)
+	return salt, b, ok
+}
+
+func startLogin(serverURL string, identity string, a *big.Int) ([]byte, *big.Int, *big.Int, bool) {
+	response, err := http.PostForm(serverURL + "/start", url.Values{"I": {identity}, "A": {a.Text(16)}})
+	if err != nil { return nil, nil, nil, false }
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK { return nil, nil, nil, false }
+
+	var start startResponse
+	if json.NewDecoder(response.Body).Decode(&start) != nil { return nil, nil, nil, false }
+	salt, err := hex.DecodeString(start.Salt)
+	b, ok := new(big.Int).SetString(start.B, 16)
+	u, _ := new(big.Int).SetString(start.U, 16)
+	return salt, b, u, err == nil && ok
+}
+
+// Sends the proof to the server. Returns `true` if the login was successful.
+func FinishLogin(serverURL string, identity string, mac []byte) bool {
+	response, err := http.PostForm(serverURL + "/verify", url.Values{"I": {identity}, "mac": {hex.EncodeToString(mac)}})
+	if err != nil { return false }
+	response.Body.Close()
+	return response.StatusCode == http.StatusOK
+}
+
+func Login(serverURL string, identity string, password []byte) bool {
+	a := randomInt()
+	bigA := new(big.Int).Exp(G, a, N)
+	salt, bigB, ok := StartLogin(serverURL, identity, bigA)
+	if !ok { return false }
+
+	// S = (B - k * g^x)^(a + u * x) mod N
+	u := hashInt(bigA.Bytes(), bigB.Bytes())
+	x := PasswordHash(salt, password)
+	base := new(big.Int).Exp(G, x, N)
+	base.Mul(base, K)
+	base.Sub(bigB, base)
+	base.Mod(base, N)
+	exponent := new(big.Int).Mul(u, x)
+	exponent.Add(exponent, a)
+	s := new(big.Int).Exp(base, exponent, N)
+
+	return FinishLogin(serverURL, identity, HMAC(Hash(s.Bytes()), salt))
+}
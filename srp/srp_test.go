@@ -0,0 +1,37 @@
+package srp
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLogin(t *testing.T) {
+	server := NewServer()
+	server.Register("alice@example.com", []byte("hunter2"))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	if !Login(ts.URL, "alice@example.com", []byte("hunter2")) {
+		t.Errorf("login failed with the correct password")
+	}
+	if Login(ts.URL, "alice@example.com", []byte("hunter3")) {
+		t.Errorf("login succeeded with the wrong password")
+	}
+	if Login(ts.URL, "bob@example.com", []byte("hunter2")) {
+		t.Errorf("login succeeded with an unknown user")
+	}
+}
+
+func TestSimplifiedLogin(t *testing.T) {
+	server := NewSimplifiedServer()
+	server.Register("alice@example.com", []byte("hunter2"))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	if !SimplifiedLogin(ts.URL, "alice@example.com", []byte("hunter2")) {
+		t.Errorf("login failed with the correct password")
+	}
+	if SimplifiedLogin(ts.URL, "alice@example.com", []byte("hunter3")) {
+		t.Errorf("login succeeded with the wrong password")
+	}
+}
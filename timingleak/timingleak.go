@@ -0,0 +1,58 @@
+package timingleak
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"time"
+	"../mdhash"
+)
+
+// A web server that verifies HMAC-SHA1 signatures of file names with a comparison that
+// returns early and sleeps after each byte, so that the time it takes to reject a signature
+// leaks how many of its bytes are correct. It is a http.Handler, so it can be run with
+// net/http/httptest.
+//
+// GET /test?file=foo&signature=46b4ec586117154dacd49d664e5d63fdc88efb51
+//
+// returns 200 if the signature is valid, 500 otherwise.
+
+type Server struct {
+	key []byte
+	Delay time.Duration // Artificial delay per byte
+}
+
+func NewServer(key []byte, delay time.Duration) *Server {
+	output := new(Server)
+	output.key = key
+	output.Delay = delay
+	return output
+}
+
+func (this *Server) Sign(file []byte) []byte {
+	mac := hmac.New(func() hash.Hash { return mdhash.NewSHA1() }, this.key)
+	mac.Write(file)
+	return mac.Sum(nil)
+}
+
+func (this *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	file := r.URL.Query().Get("file")
+	signature, err := hex.DecodeString(r.URL.Query().Get("signature"))
+	if err != nil || !InsecureCompare(this.Sign([]byte(file)), signature, this.Delay) {
+		http.Error(w, "Invalid signature", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("OK"))
+}
+
+// Same as cryptoutil.SliceEquals (returns on the first mismatch), but sleeps after each byte
+// to make the timing leak easier to measure.
+func InsecureCompare(slice1 []byte, slice2 []byte, delay time.Duration) bool {
+	if len(slice1) != len(slice2) { return false }
+	for i := 0; i < len(slice1); i++ {
+		if slice1[i] != slice2[i] { return false }
+		time.Sleep(delay)
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package timingleak
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServer(t *testing.T) {
+	server := NewServer([]byte("YELLOW SUBMARINE"), time.Millisecond)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	mac := server.Sign([]byte("foo"))
+	signature := hex.EncodeToString(mac)
+	// Same signature with the first byte changed
+	tampered := append([]byte{mac[0] ^ 0xff}, mac[1:]...)
+	for _, test := range []struct {
+		file string
+		signature string
+		status int
+	}{
+		{"foo", signature, http.StatusOK},
+		{"bar", signature, http.StatusInternalServerError},
+		{"foo", hex.EncodeToString(tampered), http.StatusInternalServerError},
+		{"foo", "nothex", http.StatusInternalServerError},
+	} {
+		response, err := http.Get(ts.URL + "/test?file=" + test.file + "&signature=" + test.signature)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != test.status {
+			t.Errorf("%s %s: expected status %d, got %d", test.file, test.signature, test.status, response.StatusCode)
+		}
+	}
+}
+
+func TestInsecureCompare(t *testing.T) {
+	a := []byte("abcdefgh")
+	start := time.Now()
+	if InsecureCompare(a, []byte("abcdXXXX"), 5 * time.Millisecond) {
+		t.Errorf("different slices are equal")
+	}
+	// Four bytes are correct, so it should take at least 20ms
+	if elapsed := time.Since(start); elapsed < 20 * time.Millisecond {
+		t.Errorf("comparison took %v", elapsed)
+	}
+}
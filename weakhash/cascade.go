@@ -0,0 +1,60 @@
+package weakhash
+
+import (
+	"../cryptoutil"
+)
+
+// Cascade of two hashes: H(m) = f(m) || g(m). One might expect the output to be as strong as a
+// hash with the combined size, but with Joux multicollisions it is barely stronger than g:
+//
+// - Build a multicollision of 2^(g.Bits / 2) messages for f, which only costs g.Bits / 2
+//   collisions of f.
+// - All of them have the same f hash, so a g collision among them is a collision of the
+//   cascade. By the birthday paradox there is one with good probability, and if not we add
+//   one more collision to the multicollision to double the number of messages.
+
+type Cascade struct {
+	F *Hash
+	G *Hash
+}
+
+func (this *Cascade) Sum(message []byte) []byte {
+	return cryptoutil.AppendBytes(this.F.Sum(message), this.G.Sum(message))
+}
+
+// Returns two different messages with the same cascade hash
+func FindCascadeCollision(cascade *Cascade, workers int) ([]byte, []byte) {
+	n := cascade.G.Bits / 2
+	collisions := Multicollision(cascade.F, cascade.F.IV(), n, workers)
+	for {
+		// All the messages have the same length, so the same padding
+		length := len(collisions) * BlockSize
+		seen := make(map[uint64]uint64)
+		var found bool
+		var i1, i2 uint64
+
+		// Depth-first traversal of the tree of messages, so that each prefix is only hashed
+		// once with g: the leaves are the 2^n messages.
+		var walk func(state []byte, depth int, index uint64)
+		walk = func(state []byte, depth int, index uint64) {
+			if found { return }
+			if depth == len(collisions) {
+				key := stateKey(cascade.G.Finish(state, nil, length))
+				if other, ok := seen[key]; ok {
+					found, i1, i2 = true, other, index
+				}
+				seen[key] = index
+				return
+			}
+			c := collisions[depth]
+			walk(cascade.G.Compress(state, c.Block1), depth + 1, index)
+			walk(cascade.G.Compress(state, c.Block2), depth + 1, index | uint64(1) << uint(depth))
+		}
+		walk(cascade.G.IV(), 0, 0)
+
+		if found {
+			return CollisionMessage(collisions, i1), CollisionMessage(collisions, i2)
+		}
+		collisions = append(collisions, FindCollision(cascade.F, collisions[len(collisions) - 1].State, workers))
+	}
+}
@@ -0,0 +1,184 @@
+package weakhash
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+	"../cryptoutil"
+)
+
+// Two different blocks that give the same state from a given state
+type Collision struct {
+	Block1 []byte
+	Block2 []byte
+	State []byte // state after either block
+}
+
+// Birthday attack on the compression function: hashes random blocks from the given state until
+// two of them give the same output, which takes about 2^(bits / 2) calls. The blocks are
+// generated by several goroutines that share a table of the outputs seen so far.
+func FindCollision(h *Hash, state []byte, workers int) Collision {
+	if workers < 1 { workers = 1 }
+	var mutex sync.Mutex
+	seen := make(map[uint64][]byte)
+	var result *Collision
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			// The table is updated in batches to limit contention on the lock
+			batchSize := 64
+			blocks := make([][]byte, batchSize)
+			states := make([][]byte, batchSize)
+			for {
+				for i := range blocks {
+					blocks[i] = make([]byte, BlockSize)
+					r.Read(blocks[i])
+					states[i] = h.Compress(state, blocks[i])
+				}
+				mutex.Lock()
+				for i := range blocks {
+					if result != nil { break }
+					key := stateKey(states[i])
+					other, found := seen[key]
+					if found && !cryptoutil.SliceEquals(other, blocks[i]) {
+						result = &Collision{other, blocks[i], states[i]}
+					}
+					seen[key] = blocks[i]
+				}
+				done := result != nil
+				mutex.Unlock()
+				if done { return }
+			}
+		}(time.Now().UnixNano() + int64(w))
+	}
+	wg.Wait()
+	return *result
+}
+
+// Finds blocks b1 and b2 such that Compress(state1, b1) = Compress(state2, b2), with a
+// birthday search on both sides: about 2^(bits / 2) calls for each state.
+func FindCrossCollision(h *Hash, state1 []byte, state2 []byte, workers int) Collision {
+	if workers < 1 { workers = 1 }
+	var mutex sync.Mutex
+	seen := []map[uint64][]byte{make(map[uint64][]byte), make(map[uint64][]byte)}
+	states := [][]byte{state1, state2}
+	var result *Collision
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			batchSize := 64
+			blocks := make([][]byte, batchSize)
+			outputs := make([][]byte, batchSize)
+			for {
+				// Alternate between the two sides
+				for i := range blocks {
+					blocks[i] = make([]byte, BlockSize)
+					r.Read(blocks[i])
+					outputs[i] = h.Compress(states[i % 2], blocks[i])
+				}
+				mutex.Lock()
+				for i := range blocks {
+					if result != nil { break }
+					side := i % 2
+					key := stateKey(outputs[i])
+					if other, found := seen[1 - side][key]; found {
+						if side == 0 {
+							result = &Collision{blocks[i], other, outputs[i]}
+						} else {
+							result = &Collision{other, blocks[i], outputs[i]}
+						}
+					}
+					seen[side][key] = blocks[i]
+				}
+				done := result != nil
+				mutex.Unlock()
+				if done { return }
+			}
+		}(time.Now().UnixNano() + int64(w))
+	}
+	wg.Wait()
+	return *result
+}
+
+// Joux multicollisions: n successive collisions, starting from the given state. Since each
+// collision leads to the same state, any choice of one block from each collision gives the
+// same final state, so this gives 2^n colliding messages of n blocks for the cost of n
+// collisions, instead of 2^(bits * (2^n - 1) / 2^n) for a generic multicollision.
+func Multicollision(h *Hash, state []byte, n int, workers int) []Collision {
+	var output []Collision
+	for i := 0; i < n; i++ {
+		c := FindCollision(h, state, workers)
+		output = append(output, c)
+		state = c.State
+	}
+	return output
+}
+
+// Returns the i-th message of the multicollision: block j is Block2 if bit j of i is set
+func CollisionMessage(collisions []Collision, i uint64) []byte {
+	var output []byte
+	for j, c := range collisions {
+		if i >> uint(j) & 1 == 1 {
+			output = cryptoutil.AppendBytes(output, c.Block2)
+		} else {
+			output = cryptoutil.AppendBytes(output, c.Block1)
+		}
+	}
+	return output
+}
+
+// Returns the 2^n messages of the multicollision
+func CollisionMessages(collisions []Collision) [][]byte {
+	var output [][]byte
+	for i := uint64(0); i < uint64(1) << uint(len(collisions)); i++ {
+		output = append(output, CollisionMessage(collisions, i))
+	}
+	return output
+}
+
+// Brute force search for a block that takes the state to one of the targets (indexed by
+// stateKey), which takes about 2^bits / len(targets) calls. Returns the block and the value
+// associated with the target it reaches.
+func findBlockToTargets(h *Hash, state []byte, targets map[uint64]int, workers int) ([]byte, int) {
+	if workers < 1 { workers = 1 }
+	var mutex sync.Mutex
+	var result []byte
+	index := -1
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			block := make([]byte, BlockSize)
+			for i := 0; ; i++ {
+				if i % 1024 == 0 {
+					mutex.Lock()
+					done := result != nil
+					mutex.Unlock()
+					if done { return }
+				}
+				r.Read(block)
+				j, found := targets[stateKey(h.Compress(state, block))]
+				if !found { continue }
+				mutex.Lock()
+				if result == nil {
+					result = cryptoutil.AppendBytes([]byte{}, block)
+					index = j
+				}
+				mutex.Unlock()
+				return
+			}
+		}(time.Now().UnixNano() + int64(w))
+	}
+	wg.Wait()
+	return result, index
+}
@@ -0,0 +1,79 @@
+package weakhash
+
+import (
+	"../cryptoutil"
+)
+
+// Kelsey-Schneier expandable message: a set of messages of any length between k and
+// k + 2^k - 1 blocks that all lead to the same state.
+//
+// It is made of k collisions between a 1-block message and a (2^i + 1)-block message, for i
+// from k - 1 down to 0. The long message is 2^i dummy blocks followed by a block that collides
+// with the short one. Picking the long or short message for each i adds 2^i blocks or not, so
+// the length can be adjusted like a binary number.
+type ExpandableMessage struct {
+	K int
+	Short [][]byte // one block for each i
+	Long [][]byte // 2^i + 1 blocks for each i
+	State []byte // final state
+}
+
+func NewExpandableMessage(h *Hash, state []byte, k int, workers int) *ExpandableMessage {
+	output := &ExpandableMessage{K: k}
+	for i := k - 1; i >= 0; i-- {
+		dummy := make([]byte, BlockSize * (1 << uint(i)))
+		c := FindCrossCollision(h, state, h.Iterate(state, dummy), workers)
+		output.Short = append(output.Short, c.Block1)
+		output.Long = append(output.Long, cryptoutil.AppendBytes(dummy, c.Block2))
+		state = c.State
+	}
+	output.State = state
+	return output
+}
+
+// Returns the message with the given number of blocks, or `false` if it is out of range
+func (this *ExpandableMessage) Message(blocks int) ([]byte, bool) {
+	extra := blocks - this.K
+	if extra < 0 || extra >= 1 << uint(this.K) { return nil, false }
+	var output []byte
+	for j := 0; j < this.K; j++ {
+		// Short[j] and Long[j] are for i = K - 1 - j
+		if extra >> uint(this.K - 1 - j) & 1 == 1 {
+			output = cryptoutil.AppendBytes(output, this.Long[j])
+		} else {
+			output = cryptoutil.AppendBytes(output, this.Short[j])
+		}
+	}
+	return output, true
+}
+
+// Long message second preimage: given a message of about 2^k blocks, finds a different message
+// with the same hash, in about k * 2^(bits / 2) + 2^(bits - k) calls instead of 2^bits.
+//
+// We look for a "bridge" block that takes the final state of an expandable message to one of
+// the intermediate states of the message. Then the expandable message, adjusted to the right
+// length, followed by the bridge block and the rest of the message, has the same length and
+// goes through the same state, so it has the same hash (the padding includes the length).
+// Returns `false` if the message is too short.
+func SecondPreimage(h *Hash, message []byte, k int, workers int) ([]byte, bool) {
+	blocks := len(message) / BlockSize
+	// The bridge replaces block j, after an expandable message of j blocks
+	if blocks <= k { return nil, false }
+
+	targets := make(map[uint64]int)
+	state := h.IV()
+	for j := 0; j < blocks; j++ {
+		state = h.Compress(state, message[j * BlockSize:(j + 1) * BlockSize])
+		if j >= k && j < k + 1 << uint(k) {
+			targets[stateKey(state)] = j
+		}
+	}
+
+	expandable := NewExpandableMessage(h, h.IV(), k, workers)
+
+	bridge, position := findBlockToTargets(h, expandable.State, targets, workers)
+	prefix, ok := expandable.Message(position)
+	if !ok { return nil, false }
+	output := cryptoutil.AppendBytes(prefix, bridge)
+	return cryptoutil.AppendBytes(output, message[(position + 1) * BlockSize:]), true
+}
@@ -0,0 +1,157 @@
+package weakhash
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+	"../cryptoutil"
+)
+
+// Kelsey-Kohno herding ("Nostradamus") attack: commit to a hash, then produce a message with any
+// chosen prefix that has this hash.
+//
+// The diamond structure is a binary tree of collisions: 2^k starting states are paired, and for
+// each pair we find blocks that lead both states to the same new state, giving 2^(k-1) states,
+// and so on until a single final state. The hash we commit to is this final state followed by the
+// padding for the final length. Once we know the prefix, we look for a "link" block that takes the
+// state after the prefix to one of the 2^k leaves (about 2^(bits - k) calls), then follow the path
+// from that leaf to the root.
+//
+// Building the diamond is the expensive part (about 2^(k + bits / 2) calls), so it is saved to
+// a file as it progresses and the construction resumes from there if it is interrupted.
+
+type Diamond struct {
+	K int
+	Bits int
+	// States[l] has the 2^(K - l) states of level l, States[K] only has the final state
+	States [][][]byte
+	// Blocks[l][i] takes States[l][i] to States[l + 1][i / 2]
+	Blocks [][][]byte
+}
+
+func LoadDiamond(path string) (*Diamond, bool) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil { return nil, false }
+	var output Diamond
+	if err := json.Unmarshal(data, &output); err != nil { return nil, false }
+	return &output, true
+}
+
+// Tells whether a loaded diamond has the expected shape for 2^k leaves. A truncated or
+// hand-edited checkpoint could otherwise make the construction index out of range.
+func (this *Diamond) valid(h *Hash, k int) bool {
+	if this.K != k || this.Bits != h.Bits { return false }
+	if len(this.States) != k + 1 || len(this.Blocks) != k { return false }
+	for l := 0; l <= k; l++ {
+		if len(this.States[l]) != 1 << uint(k - l) { return false }
+		for _, state := range this.States[l] {
+			// Only the leaves must all be there, the other states may not be computed yet
+			if state == nil && l == 0 { return false }
+			if state != nil && len(state) != h.Size() { return false }
+		}
+	}
+	for l := 0; l < k; l++ {
+		if len(this.Blocks[l]) != 1 << uint(k - l) { return false }
+		for i, block := range this.Blocks[l] {
+			// The states and blocks leading to a state that has been computed must be there
+			if this.States[l + 1][i / 2] != nil && (len(block) != BlockSize || this.States[l][i] == nil) { return false }
+		}
+	}
+	return true
+}
+
+// Writes to a temporary file first, so that an interruption doesn't leave a broken checkpoint
+func (this *Diamond) Save(path string) bool {
+	data, err := json.Marshal(this)
+	if err != nil { return false }
+	if err := ioutil.WriteFile(path + ".tmp", data, 0644); err != nil { return false }
+	return os.Rename(path + ".tmp", path) == nil
+}
+
+func newDiamond(h *Hash, k int) *Diamond {
+	output := &Diamond{K: k, Bits: h.Bits}
+	// Random leaves, which must all be different
+	var leaves [][]byte
+	seen := make(map[uint64]bool)
+	for len(leaves) < 1 << uint(k) {
+		state := h.truncate(cryptoutil.RandomBytes(h.Size()))
+		if seen[stateKey(state)] { continue }
+		seen[stateKey(state)] = true
+		leaves = append(leaves, state)
+	}
+	output.States = append(output.States, leaves)
+	for l := 1; l <= k; l++ {
+		output.States = append(output.States, make([][]byte, 1 << uint(k - l)))
+	}
+	for l := 0; l < k; l++ {
+		output.Blocks = append(output.Blocks, make([][]byte, 1 << uint(k - l)))
+	}
+	return output
+}
+
+// Builds a diamond of 2^k leaves, or resumes building the one saved in the checkpoint file (if
+// the path is not empty and the file holds a valid diamond for these parameters). The checkpoint
+// is updated at most every few seconds, and at the end of each level. A failure to save it is
+// logged but doesn't stop the construction.
+func BuildDiamond(h *Hash, k int, workers int, checkpoint string) *Diamond {
+	var diamond *Diamond
+	if checkpoint != "" {
+		if saved, ok := LoadDiamond(checkpoint); ok && saved.valid(h, k) {
+			diamond = saved
+		}
+	}
+	if diamond == nil { diamond = newDiamond(h, k) }
+
+	lastSave := time.Now()
+	save := func() {
+		if !diamond.Save(checkpoint) { log.Println("weakhash: could not save checkpoint", checkpoint) }
+		lastSave = time.Now()
+	}
+	for l := 0; l < k; l++ {
+		for i := 0; i < len(diamond.States[l]); i += 2 {
+			if diamond.States[l + 1][i / 2] != nil { continue }
+			c := FindCrossCollision(h, diamond.States[l][i], diamond.States[l][i + 1], workers)
+			diamond.Blocks[l][i] = c.Block1
+			diamond.Blocks[l][i + 1] = c.Block2
+			diamond.States[l + 1][i / 2] = c.State
+			if checkpoint != "" && time.Since(lastSave) > 5 * time.Second { save() }
+		}
+		if checkpoint != "" { save() }
+	}
+	return diamond
+}
+
+// Number of blocks added after the prefix: the link block and the path to the root
+func (this *Diamond) SuffixBlocks() int {
+	return this.K + 1
+}
+
+// The hash to commit to, for a prefix of prefixBlocks blocks
+func (this *Diamond) Prediction(h *Hash, prefixBlocks int) []byte {
+	return h.Finish(this.States[this.K][0], nil, (prefixBlocks + this.SuffixBlocks()) * BlockSize)
+}
+
+// Returns the blocks that take leaf i to the final state
+func (this *Diamond) Path(i int) []byte {
+	var output []byte
+	for l := 0; l < this.K; l++ {
+		output = cryptoutil.AppendBytes(output, this.Blocks[l][i])
+		i /= 2
+	}
+	return output
+}
+
+// Returns a message starting with the prefix (a multiple of the block size), whose hash is the
+// prediction for that number of blocks.
+func (this *Diamond) Herd(h *Hash, prefix []byte, workers int) []byte {
+	leaves := make(map[uint64]int)
+	for i, state := range this.States[0] {
+		leaves[stateKey(state)] = i
+	}
+
+	link, leaf := findBlockToTargets(h, h.Iterate(h.IV(), prefix), leaves, workers)
+	output := cryptoutil.AppendBytes(cryptoutil.AppendBytes([]byte{}, prefix), link)
+	return cryptoutil.AppendBytes(output, this.Path(leaf))
+}
@@ -0,0 +1,109 @@
+package weakhash
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+	"../cryptoutil"
+)
+
+// A Merkle-Damgård hash with a deliberately small state (16 to 32 bits), so that generic
+// attacks on iterated hashes can be run in practice.
+//
+// The compression function encrypts the state with AES, using the message block as the key:
+//
+// H[i] = truncate(AES(key = M[i], H[i-1] || 0 ... 0))
+//
+// The message is padded with a 1 bit, zeros, and its length in bits on 64 bits, like MD4
+// and SHA-1 but with 16-byte blocks.
+
+const BlockSize = 16
+
+type Hash struct {
+	Bits int
+	calls uint64
+}
+
+func New(bits int) *Hash {
+	if bits < 8 || bits > 64 { panic("weakhash: invalid state size") }
+	return &Hash{Bits: bits}
+}
+
+// Size of the state in bytes
+func (this *Hash) Size() int {
+	return (this.Bits + 7) / 8
+}
+
+// Initial state
+func (this *Hash) IV() []byte {
+	iv := make([]byte, this.Size())
+	for i := range iv {
+		iv[i] = byte(0x5a + 0x11 * i)
+	}
+	return this.truncate(iv)
+}
+
+// Clears the bits that are not part of the state
+func (this *Hash) truncate(state []byte) []byte {
+	if extra := this.Size() * 8 - this.Bits; extra > 0 {
+		state[len(state) - 1] &= byte(0xff << uint(extra))
+	}
+	return state
+}
+
+func (this *Hash) Compress(state []byte, block []byte) []byte {
+	atomic.AddUint64(&this.calls, 1)
+	padded := make([]byte, BlockSize)
+	copy(padded, state)
+	encrypted := cryptoutil.AES128ECBEncrypt(padded, block)
+	return this.truncate(encrypted[0:this.Size()])
+}
+
+// Returns the state after processing the message, which must be a multiple of the block size,
+// without any padding.
+func (this *Hash) Iterate(state []byte, message []byte) []byte {
+	for i := 0; i < len(message); i += BlockSize {
+		state = this.Compress(state, message[i:i + BlockSize])
+	}
+	return state
+}
+
+// Padding for a message of the given length (in bytes)
+func Padding(length int) []byte {
+	padding := []byte{0x80}
+	for (length + len(padding)) % BlockSize != BlockSize - 8 {
+		padding = append(padding, 0)
+	}
+	bits := make([]byte, 8)
+	binary.BigEndian.PutUint64(bits, uint64(length) * 8)
+	return cryptoutil.AppendBytes(padding, bits)
+}
+
+func (this *Hash) Sum(message []byte) []byte {
+	return this.Finish(this.Iterate(this.IV(), message[0:len(message) - len(message) % BlockSize]),
+		message[len(message) - len(message) % BlockSize:], len(message))
+}
+
+// Processes the end of the message (less than one block) and the padding, from the given state.
+// length is the length of the whole message.
+func (this *Hash) Finish(state []byte, tail []byte, length int) []byte {
+	last := cryptoutil.AppendBytes(cryptoutil.AppendBytes([]byte{}, tail), Padding(length))
+	return this.Iterate(state, last)
+}
+
+// Number of calls to the compression function so far
+func (this *Hash) Calls() uint64 {
+	return atomic.LoadUint64(&this.calls)
+}
+
+func (this *Hash) ResetCalls() {
+	atomic.StoreUint64(&this.calls, 0)
+}
+
+// Used to index states in maps
+func stateKey(state []byte) uint64 {
+	var output uint64
+	for _, b := range state {
+		output = output << 8 | uint64(b)
+	}
+	return output
+}
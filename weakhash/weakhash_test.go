@@ -0,0 +1,145 @@
+package weakhash
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"../cryptoutil"
+)
+
+func TestSum(t *testing.T) {
+	h := New(20)
+	message := []byte("Yellow submarine, Merkle-Damgard")
+	digest := h.Sum(message)
+	if len(digest) != 3 || digest[2] & 0x0f != 0 {
+		t.Errorf("digest should have 20 bits, got %x", digest)
+	}
+	// Same as iterating over the padded message
+	padded := cryptoutil.AppendBytes(cryptoutil.AppendBytes([]byte{}, message), Padding(len(message)))
+	if len(padded) % BlockSize != 0 || !cryptoutil.SliceEquals(h.Iterate(h.IV(), padded), digest) {
+		t.Errorf("Sum and Iterate disagree")
+	}
+	if cryptoutil.SliceEquals(h.Sum([]byte("Yellow submarine")), h.Sum([]byte("Yellow submarinf"))) {
+		t.Errorf("the hash doesn't depend on the message")
+	}
+}
+
+func TestMulticollision(t *testing.T) {
+	h := New(16)
+	collisions := Multicollision(h, h.IV(), 4, 4)
+	messages := CollisionMessages(collisions)
+	if len(messages) != 16 {
+		t.Fatalf("expected 16 messages, got %d", len(messages))
+	}
+	expected := h.Sum(messages[0])
+	seen := make(map[string]bool)
+	for _, m := range messages {
+		if !cryptoutil.SliceEquals(h.Sum(m), expected) {
+			t.Errorf("%x doesn't collide", m)
+		}
+		seen[string(m)] = true
+	}
+	if len(seen) != len(messages) {
+		t.Errorf("the messages are not all different")
+	}
+}
+
+func TestCascadeCollision(t *testing.T) {
+	cascade := &Cascade{New(16), New(24)}
+	m1, m2 := FindCascadeCollision(cascade, 2)
+	if cryptoutil.SliceEquals(m1, m2) || !cryptoutil.SliceEquals(cascade.Sum(m1), cascade.Sum(m2)) {
+		t.Errorf("not a collision: %x %x", m1, m2)
+	}
+}
+
+func TestExpandableMessage(t *testing.T) {
+	h := New(16)
+	k := 4
+	expandable := NewExpandableMessage(h, h.IV(), k, 2)
+	for blocks := k; blocks < k + 1 << uint(k); blocks++ {
+		m, ok := expandable.Message(blocks)
+		if !ok || len(m) != blocks * BlockSize || !cryptoutil.SliceEquals(h.Iterate(h.IV(), m), expandable.State) {
+			t.Errorf("wrong message for %d blocks", blocks)
+		}
+	}
+	if _, ok := expandable.Message(k + 1 << uint(k)); ok {
+		t.Errorf("message too long accepted")
+	}
+}
+
+func TestSecondPreimage(t *testing.T) {
+	h := New(20)
+	k := 8
+	message := cryptoutil.RandomBytes(BlockSize * (1 << uint(k)) + 5)
+	preimage, ok := SecondPreimage(h, message, k, 2)
+	if !ok || cryptoutil.SliceEquals(preimage, message) || !cryptoutil.SliceEquals(h.Sum(preimage), h.Sum(message)) {
+		t.Errorf("not a second preimage")
+	}
+}
+
+func TestHerding(t *testing.T) {
+	h := New(20)
+	checkpoint := filepath.Join(t.TempDir(), "diamond.json")
+	h.ResetCalls()
+	diamond := BuildDiamond(h, 6, 2, checkpoint)
+	fullCalls := h.Calls()
+
+	// The saved diamond is complete, so it is used as is
+	saved, ok := LoadDiamond(checkpoint)
+	if !ok || !cryptoutil.SliceEquals(saved.States[6][0], diamond.States[6][0]) {
+		t.Fatalf("checkpoint not saved")
+	}
+	h.ResetCalls()
+	diamond = BuildDiamond(h, 6, 2, checkpoint)
+	if h.Calls() != 0 || !cryptoutil.SliceEquals(diamond.States[6][0], saved.States[6][0]) {
+		t.Errorf("diamond not resumed from the checkpoint")
+	}
+
+	// Drop the top of the diamond and half of level 4, as if the construction had been interrupted
+	for i := 2; i < 4; i++ {
+		diamond.States[4][i] = nil
+	}
+	diamond.States[5] = make([][]byte, 2)
+	diamond.States[6] = make([][]byte, 1)
+	diamond.Save(checkpoint)
+	h.ResetCalls()
+	diamond = BuildDiamond(h, 6, 2, checkpoint)
+	if h.Calls() == 0 || h.Calls() >= fullCalls {
+		t.Errorf("resumed build made %d calls, the full build %d", h.Calls(), fullCalls)
+	}
+	for i, leaf := range diamond.States[0] {
+		if !cryptoutil.SliceEquals(h.Iterate(leaf, diamond.Path(i)), diamond.States[6][0]) {
+			t.Errorf("leaf %d doesn't lead to the root", i)
+		}
+	}
+
+	prefix := []byte("Final score: 3-1, Yellow wins!!!")
+	prediction := diamond.Prediction(h, len(prefix) / BlockSize)
+	message := diamond.Herd(h, prefix, 2)
+	if !bytes.HasPrefix(message, prefix) || !cryptoutil.SliceEquals(h.Sum(message), prediction) {
+		t.Errorf("herded message doesn't match the prediction")
+	}
+}
+
+func TestHerdingInvalidCheckpoint(t *testing.T) {
+	h := New(16)
+	checkpoint := filepath.Join(t.TempDir(), "diamond.json")
+	diamond := BuildDiamond(h, 3, 2, checkpoint)
+
+	// Truncated levels, missing blocks and wrong state sizes are all ignored
+	truncated := *diamond
+	truncated.States = truncated.States[0:2]
+	missingBlocks := *diamond
+	missingBlocks.Blocks = [][][]byte{diamond.Blocks[0], diamond.Blocks[1], make([][]byte, 2)}
+	shortState := *diamond
+	shortState.States = [][][]byte{diamond.States[0], diamond.States[1], diamond.States[2], [][]byte{{1}}}
+	for _, broken := range []Diamond{truncated, missingBlocks, shortState} {
+		broken.Save(checkpoint)
+		rebuilt := BuildDiamond(h, 3, 2, checkpoint)
+		for i, leaf := range rebuilt.States[0] {
+			if !cryptoutil.SliceEquals(h.Iterate(leaf, rebuilt.Path(i)), rebuilt.States[3][0]) {
+				t.Errorf("leaf %d doesn't lead to the root", i)
+			}
+		}
+	}
+}